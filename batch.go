@@ -0,0 +1,143 @@
+package pool
+
+import (
+	"context"
+	"errors"
+)
+
+// AcquireN acquires n objects, waiting for them like Acquire. It is all or
+// nothing: if one acquire fails, the objects acquired so far are released and
+// the error is returned. Callers holding part of the pool while waiting for
+// more may deadlock each other, so n should stay well below Max.
+func (p *GenericPool) AcquireN(n int) ([]PoolObject, error) {
+	p.Lock()
+	maxCap := p.maxCap
+	p.Unlock()
+	if n < 0 || n > maxCap {
+		return nil, ErrInvalidConfig
+	}
+	objs := make([]PoolObject, 0, n)
+	for i := 0; i < n; i++ {
+		poolObj, err := p.Acquire()
+		if err != nil {
+			for _, acquired := range objs {
+				p.Release(acquired)
+			}
+			return nil, err
+		}
+		objs = append(objs, poolObj)
+	}
+	return objs, nil
+}
+
+// ReleaseSubset releases the objects of a batch at the given indices, and
+// returns the rest, still held by the caller, in their original order. All
+// selected objects are released even if some fail, and the first error is
+// returned. Indices out of range or given twice fail with ErrNotInUse before
+// anything is released.
+func (p *GenericPool) ReleaseSubset(objs []PoolObject, indices []int) ([]PoolObject, error) {
+	selected := make([]bool, len(objs))
+	for _, i := range indices {
+		if i < 0 || i >= len(objs) || selected[i] {
+			return objs, p.misuse(ErrNotInUse)
+		}
+		selected[i] = true
+	}
+	var err error
+	remaining := make([]PoolObject, 0, len(objs)-len(indices))
+	for i, poolObj := range objs {
+		if !selected[i] {
+			remaining = append(remaining, poolObj)
+			continue
+		}
+		if e := p.Release(poolObj); e != nil && err == nil {
+			err = e
+		}
+	}
+	return remaining, err
+}
+
+// AcquireDistinct acquires up to n objects with distinct keys, such as the
+// backend host of a connection, for scatter-gather requests. Idle objects are
+// taken first, then new ones are created while the pool is below Max. New
+// objects with a key already taken are pooled for others. It never waits, so
+// it returns fewer objects if there aren't n distinct ones to be had.
+func (p *GenericPool) AcquireDistinct(n int, key func(interface{}) string) ([]PoolObject, error) {
+	if n < 0 || key == nil {
+		return nil, ErrInvalidConfig
+	}
+	p.Lock()
+	if p.closed {
+		p.Unlock()
+		return nil, ErrPoolClosed
+	}
+	idle := p.idleObjects()
+	p.Unlock()
+	seen := make(map[string]bool, n)
+	objs := make([]PoolObject, 0, n)
+	for _, idleObj := range idle {
+		if len(objs) == n {
+			break
+		}
+		k := key(idleObj.Object)
+		if seen[k] {
+			continue
+		}
+		poolObj, ok, err := p.AcquireByID(idleObj.id)
+		if err != nil {
+			for _, acquired := range objs {
+				p.Release(acquired)
+			}
+			return nil, err
+		}
+		if !ok {
+			// acquired meanwhile
+			continue
+		}
+		seen[k] = true
+		objs = append(objs, poolObj)
+	}
+	for attempts := 0; len(objs) < n && attempts < n*initAttemptFactor; attempts++ {
+		poolObj, err := p.acquireNew()
+		if err != nil {
+			if len(objs) == 0 && !errors.Is(err, ErrPoolExhausted) {
+				return nil, err
+			}
+			break
+		}
+		if k := key(poolObj.Object); !seen[k] {
+			seen[k] = true
+			objs = append(objs, poolObj)
+			continue
+		}
+		p.Release(poolObj)
+	}
+	return objs, nil
+}
+
+// acquireNew acquires a new object without waiting, even if there are idle
+// ones. A pool at Max fails with an ExhaustedError.
+func (p *GenericPool) acquireNew() (poolObj PoolObject, err error) {
+	p.Lock()
+	switch {
+	case p.closed:
+		err = ErrPoolClosed
+	case p.draining:
+		err = ErrPoolDraining
+	case p.paused:
+		err = ErrPoolPaused
+	case p.curNum >= p.maxCap:
+		err = &ExhaustedError{RetryAfter: p.retryAfter()}
+	default:
+		p.reserve()
+	}
+	p.Unlock()
+	if err != nil {
+		return poolObj, err
+	}
+	if poolObj, err = p.createReserved(context.Background()); err != nil {
+		return poolObj, err
+	}
+	p.checkout(poolObj)
+	return poolObj, nil
+}
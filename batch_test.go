@@ -0,0 +1,108 @@
+package pool
+
+import "testing"
+
+func TestGenericPool_ReleaseSubset(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         0,
+		Max:         4,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	if _, err := pool.AcquireN(5); err != ErrInvalidConfig {
+		t.Fatalf("[ERR] expected ErrInvalidConfig, got %v", err)
+	}
+	objs, err := pool.AcquireN(4)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if _, err := pool.ReleaseSubset(objs, []int{1, 1}); err != ErrNotInUse {
+		t.Fatalf("[ERR] expected ErrNotInUse, got %v", err)
+	}
+
+	remaining, err := pool.ReleaseSubset(objs, []int{3, 1})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if len(remaining) != 2 || remaining[0].ID() != objs[0].ID() || remaining[1].ID() != objs[2].ID() {
+		t.Fatalf("[ERR] expected objects 0 and 2 to remain, got %v", remaining)
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.InUse != 2 {
+		t.Fatalf("[ERR] expected 2 objects released, got %+v", stats)
+	}
+
+	// the remainder is still owned, and releasable
+	if remaining, err = pool.ReleaseSubset(remaining, []int{0, 1}); err != nil || len(remaining) != 0 {
+		t.Fatalf("[ERR] expected the rest released, got %v %v", remaining, err)
+	}
+	if stats := pool.Stats(); stats.Idle != 4 || stats.InUse != 0 {
+		t.Fatalf("[ERR] expected all objects idle, got %+v", stats)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_AcquireDistinct(t *testing.T) {
+	type conn struct {
+		host string
+		n    int
+	}
+	hosts := []string{"a", "b", "c"}
+	var created int
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 4,
+		Max: 6,
+		FactoryFunc: func() (interface{}, error) {
+			// round robin over the hosts
+			created++
+			return &conn{host: hosts[created%len(hosts)], n: created}, nil
+		},
+		CloseFunc:     closer,
+		SerialFactory: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	host := func(obj interface{}) string { return obj.(*conn).host }
+	distinct := func(objs []PoolObject) bool {
+		seen := map[string]bool{}
+		for _, v := range objs {
+			if seen[host(v.Object)] {
+				return false
+			}
+			seen[host(v.Object)] = true
+		}
+		return true
+	}
+
+	// the idle objects are enough
+	objs, err := pool.AcquireDistinct(3, host)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if len(objs) != 3 || !distinct(objs) {
+		t.Fatalf("[ERR] expected 3 objects of distinct hosts, got %d", len(objs))
+	}
+	for _, v := range objs {
+		pool.Release(v)
+	}
+
+	// there are only 3 hosts, new objects of a taken host are pooled
+	objs, err = pool.AcquireDistinct(4, host)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if len(objs) != 3 || !distinct(objs) {
+		t.Fatalf("[ERR] expected 3 objects of distinct hosts, got %d", len(objs))
+	}
+	if stats := pool.Stats(); stats.InUse != 3 || stats.Total != 6 {
+		t.Fatalf("[ERR] expected the surplus pooled, got %+v", stats)
+	}
+	if err := pool.Verify(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
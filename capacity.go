@@ -0,0 +1,35 @@
+package pool
+
+// WaitForCapacity returns a channel which is closed once the pool has an idle
+// object or room to create one, or is shut down, so callers can select on it
+// alongside other work. It is only a hint: other acquires may take the
+// capacity first.
+func (p *GenericPool) WaitForCapacity() <-chan struct{} {
+	p.Lock()
+	defer p.Unlock()
+	ch := make(chan struct{})
+	if p.closed || p.hasCapacity() {
+		close(ch)
+		return ch
+	}
+	p.capacityWaiters = append(p.capacityWaiters, ch)
+	return ch
+}
+
+// hasCapacity reports whether an acquire could proceed without waiting. Must
+// be called with the lock held.
+func (p *GenericPool) hasCapacity() bool {
+	return !p.paused && (p.idleLen() > 0 || p.curNum < p.maxCap)
+}
+
+// notifyCapacity closes the channels of WaitForCapacity if there is capacity
+// now. Must be called with the lock held.
+func (p *GenericPool) notifyCapacity() {
+	if len(p.capacityWaiters) == 0 || !(p.closed || p.hasCapacity()) {
+		return
+	}
+	for _, ch := range p.capacityWaiters {
+		close(ch)
+	}
+	p.capacityWaiters = nil
+}
@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenericPool_WaitForCapacity(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	select {
+	case <-pool.WaitForCapacity():
+	default:
+		t.Fatal("[ERR] expected capacity with an idle object")
+	}
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	ready := pool.WaitForCapacity()
+	select {
+	case <-ready:
+		t.Fatal("[ERR] expected no capacity while saturated")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	go pool.Release(v)
+	select {
+	case <-ready:
+	case <-time.After(time.Second):
+		t.Fatal("[ERR] expected capacity once the object was released")
+	}
+	if _, _, err := pool.AcquireOrCreate(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
@@ -0,0 +1,20 @@
+package pool
+
+import "time"
+
+// Clock tells the pool the time, see PoolConfig.Clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (p *GenericPool) now() time.Time {
+	return p.clock.Now()
+}
+
+func (p *GenericPool) since(t time.Time) time.Duration {
+	return p.clock.Now().Sub(t)
+}
@@ -0,0 +1,117 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when advanced.
+type fakeClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Lock()
+	c.now = c.now.Add(d)
+	c.Unlock()
+}
+
+func TestGenericPool_Clock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		LiftTime:    time.Hour,
+		Clock:       clock,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if v1.CreateTime != clock.Now().UnixNano() {
+		t.Fatalf("[ERR] expected object created at the fake time, got %d", v1.CreateTime)
+	}
+	pool.Release(v1)
+
+	clock.Advance(59 * time.Minute)
+	v2, _ := pool.Acquire()
+	if v2.ID() != v1.ID() {
+		t.Fatalf("[ERR] expected object %d kept before its lifetime, got %d", v1.ID(), v2.ID())
+	}
+	pool.Release(v2)
+
+	clock.Advance(time.Minute)
+	v3, _ := pool.Acquire()
+	if v3.ID() == v1.ID() {
+		t.Fatal("[ERR] expected the expired object replaced")
+	}
+	if stats := pool.Stats(); stats.Total != 1 {
+		t.Fatalf("[ERR] expected 1 object, got %+v", stats)
+	}
+	t.Log("[SUCC]", v3.ID())
+}
+
+func TestGenericPool_TagLifeTimes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	var mu sync.Mutex
+	tags := map[string]int{}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 2,
+		Max: 2,
+		TaggedFactoryFunc: func() (interface{}, string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			// one object of each tag
+			tag := "slow"
+			if tags["fast"] == tags["slow"] {
+				tag = "fast"
+			}
+			tags[tag]++
+			return tag, tag, nil
+		},
+		CloseFunc:    closer,
+		LiftTime:     time.Hour,
+		TagLifeTimes: map[string]time.Duration{"fast": time.Minute},
+		Clock:        clock,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	created := func() (fast, slow int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return tags["fast"], tags["slow"]
+	}
+	cycle := func() {
+		v1, _ := pool.Acquire()
+		v2, _ := pool.Acquire()
+		pool.Release(v1)
+		pool.Release(v2)
+	}
+
+	clock.Advance(time.Minute)
+	cycle()
+	if fast, slow := created(); fast != 2 || slow != 1 {
+		t.Fatalf("[ERR] expected only the fast object replaced, created %d fast and %d slow", fast, slow)
+	}
+
+	clock.Advance(59 * time.Minute)
+	cycle()
+	if fast, slow := created(); fast != 3 || slow != 2 {
+		t.Fatalf("[ERR] expected both objects replaced, created %d fast and %d slow", fast, slow)
+	}
+	t.Log("[SUCC]", tags)
+}
@@ -0,0 +1,72 @@
+package pool
+
+// CombinedPool hands out a bundle of related objects, one from each of its
+// sub-pools, which are acquired and released together.
+type CombinedPool struct {
+	pools []*GenericPool
+}
+
+func NewCombinedPool(pools ...*GenericPool) (*CombinedPool, error) {
+	if len(pools) == 0 {
+		return nil, ErrInvalidConfig
+	}
+	for _, p := range pools {
+		if p == nil {
+			return nil, ErrInvalidConfig
+		}
+	}
+	return &CombinedPool{pools: pools}, nil
+}
+
+// acquire one object from every sub-pool, in the order the pools were given.
+// If any of them fails, the objects acquired so far are released and the
+// error is returned.
+func (c *CombinedPool) Acquire() ([]PoolObject, error) {
+	bundle := make([]PoolObject, 0, len(c.pools))
+	for _, p := range c.pools {
+		poolObj, err := p.Acquire()
+		if err != nil {
+			for i, acquired := range bundle {
+				c.pools[i].release(acquired)
+			}
+			return nil, err
+		}
+		bundle = append(bundle, poolObj)
+	}
+	return bundle, nil
+}
+
+// release every object of the bundle into its sub-pool. All objects are
+// released even if some fail, and the first error is returned.
+func (c *CombinedPool) Release(bundle []PoolObject) error {
+	return c.each(bundle, (*GenericPool).Release)
+}
+
+// close or delete every object of the bundle
+func (c *CombinedPool) Close(bundle []PoolObject) error {
+	return c.each(bundle, (*GenericPool).Close)
+}
+
+func (c *CombinedPool) each(bundle []PoolObject, fn func(*GenericPool, PoolObject) error) error {
+	if len(bundle) != len(c.pools) {
+		return ErrNotInUse
+	}
+	var err error
+	for i, poolObj := range bundle {
+		if e := fn(c.pools[i], poolObj); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// shutdown all sub-pools
+func (c *CombinedPool) Shutdown() error {
+	var err error
+	for _, p := range c.pools {
+		if e := p.Shutdown(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
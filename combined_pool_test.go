@@ -0,0 +1,73 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestCombinedPool_Acquire(t *testing.T) {
+	pool, err := NewGenericPool(config)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	reqPool, err := NewGenericPool(requestConfig)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	resPool, err := NewGenericPool(responseConfig)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	combined, err := NewCombinedPool(pool, reqPool, resPool)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	for i := 0; i < 10; i++ {
+		bundle, err := combined.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		client := bundle[0].Object.(*fasthttp.Client)
+		req := bundle[1].Object.(*fasthttp.Request)
+		res := bundle[2].Object.(*fasthttp.Response)
+		req.SetRequestURI("http://www.google.com.hk")
+		if err := client.Do(req, res); err != nil {
+			t.Log("[ERR]", err)
+		}
+		if err := combined.Release(bundle); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	for _, p := range []*GenericPool{pool, reqPool, resPool} {
+		if stats := p.Stats(); stats.InUse != 0 {
+			t.Fatalf("[ERR] expected all objects released, %d in use", stats.InUse)
+		}
+	}
+
+	// a failing sub-pool gives back the objects already acquired
+	failing, err := NewGenericPool(&PoolConfig{
+		Min:            1,
+		Max:            1,
+		FactoryFunc:    factory,
+		CloseFunc:      closer,
+		FailWhenPaused: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	failing.Pause()
+	combined, err = NewCombinedPool(pool, reqPool, failing)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if _, err := combined.Acquire(); err != ErrPoolPaused {
+		t.Fatalf("[ERR] expected ErrPoolPaused, got %v", err)
+	}
+	for _, p := range []*GenericPool{pool, reqPool} {
+		if stats := p.Stats(); stats.InUse != 0 {
+			t.Fatalf("[ERR] expected partial bundle released, %d in use", stats.InUse)
+		}
+	}
+	t.Log("[SUCC]", pool.Len(), reqPool.Len(), resPool.Len())
+}
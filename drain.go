@@ -0,0 +1,45 @@
+package pool
+
+// drainProgress reports objects closed by a shutdown to OnDrainProgress.
+type drainProgress struct {
+	p      *GenericPool
+	closed int
+	total  int
+}
+
+// drainProgress starts reporting on total objects to close. Must be called
+// with the lock held, as must done.
+func (p *GenericPool) drainProgress(total int) *drainProgress {
+	return &drainProgress{p: p, total: total}
+}
+
+// done reports n more objects closed, one call of OnDrainProgress each.
+func (d *drainProgress) done(n int) {
+	for ; n > 0 && d.closed < d.total; n-- {
+		d.closed++
+		if d.p.onDrainProgress != nil {
+			closed := d.closed
+			d.p.protect("OnDrainProgress", func() error {
+				d.p.onDrainProgress(closed, d.total)
+				return nil
+			})
+		}
+	}
+}
+
+// CloseIdle closes all idle objects, leaving the objects in use alone. The
+// pool refills up to Min on following acquires.
+func (p *GenericPool) CloseIdle() error {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	idle := p.drainIdle()
+	progress := p.drainProgress(len(idle))
+	for _, poolObj := range idle {
+		p.discard(poolObj)
+		progress.done(1)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// progressRecorder records the calls of OnDrainProgress.
+type progressRecorder struct {
+	sync.Mutex
+	calls [][2]int
+}
+
+func (r *progressRecorder) record(closed, total int) {
+	r.Lock()
+	r.calls = append(r.calls, [2]int{closed, total})
+	r.Unlock()
+}
+
+// check verifies closed counts went up one by one, ending at total.
+func (r *progressRecorder) check(t *testing.T, total int) {
+	t.Helper()
+	r.Lock()
+	defer r.Unlock()
+	if len(r.calls) != total {
+		t.Fatalf("[ERR] expected %d progress calls, got %v", total, r.calls)
+	}
+	for i, call := range r.calls {
+		if call != [2]int{i + 1, total} {
+			t.Fatalf("[ERR] expected progress %d/%d, got %v", i+1, total, r.calls)
+		}
+	}
+}
+
+func TestGenericPool_OnDrainProgress(t *testing.T) {
+	newPool := func(r *progressRecorder) *GenericPool {
+		pool, err := NewGenericPool(&PoolConfig{
+			Min:             4,
+			Max:             6,
+			FactoryFunc:     factory,
+			CloseFunc:       closer,
+			OnDrainProgress: r.record,
+		})
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		return pool
+	}
+
+	var shutdown progressRecorder
+	if err := newPool(&shutdown).Shutdown(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	shutdown.check(t, 4)
+
+	var closeIdle progressRecorder
+	pool := newPool(&closeIdle)
+	v, _ := pool.Acquire()
+	if err := pool.CloseIdle(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	closeIdle.check(t, 3)
+	if stats := pool.Stats(); stats.Idle != 0 || stats.InUse != 1 {
+		t.Fatalf("[ERR] expected only the object in use left, got %+v", stats)
+	}
+	pool.Release(v)
+	pool.Shutdown()
+
+	// the objects in use count once released
+	var drain progressRecorder
+	pool = newPool(&drain)
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		pool.Release(v1)
+		time.Sleep(20 * time.Millisecond)
+		pool.Release(v2)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.ShutdownContext(ctx); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	drain.check(t, 4)
+	t.Log("[SUCC]", drain.calls)
+}
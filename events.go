@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const eventBuffer = 256 // events kept for a slow consumer before dropping
+
+type EventType int
+
+const (
+	EventCreated  EventType = iota // object created by the factory
+	EventAcquired                  // object checked out
+	EventReleased                  // object pooled again
+	EventClosed                    // object closed
+	EventEvicted                   // object closed by EvictWhere or EvictByTag
+	EventExpired                   // object closed after its LiftTime, or a Recycle
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventCreated:
+		return "created"
+	case EventAcquired:
+		return "acquired"
+	case EventReleased:
+		return "released"
+	case EventClosed:
+		return "closed"
+	case EventEvicted:
+		return "evicted"
+	case EventExpired:
+		return "expired"
+	}
+	return "unknown"
+}
+
+// PoolEvent is a step in the lifecycle of an object.
+type PoolEvent struct {
+	Type EventType
+	ID   uint64 // PoolObject.ID of the object
+	Time time.Time
+}
+
+// Events returns a channel of the lifecycle events of all objects from now
+// on. Events are dropped while the channel is full, see Stats.DroppedEvents.
+// All calls return the same channel.
+func (p *GenericPool) Events() <-chan PoolEvent {
+	p.Lock()
+	defer p.Unlock()
+	if ch, ok := p.events.Load().(chan PoolEvent); ok {
+		return ch
+	}
+	ch := make(chan PoolEvent, eventBuffer)
+	p.events.Store(ch)
+	return ch
+}
+
+// emit sends an event if anybody called Events, without ever blocking
+func (p *GenericPool) emit(kind EventType, poolObj PoolObject) {
+	ch, ok := p.events.Load().(chan PoolEvent)
+	if !ok {
+		return
+	}
+	select {
+	case ch <- PoolEvent{Type: kind, ID: poolObj.id, Time: p.now()}:
+	default:
+		atomic.AddInt64(&p.droppedEvents, 1)
+	}
+}
+
+// closeAs closes a pool object, and reports the close as an event of kind.
+func (p *GenericPool) closeAs(poolObj PoolObject, kind EventType) error {
+	err := p.closeObject(poolObj.Object)
+	p.emit(kind, poolObj)
+	return err
+}
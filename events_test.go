@@ -0,0 +1,66 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenericPool_Events(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         0,
+		Max:         2,
+		LiftTime:    time.Hour,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	events := pool.Events()
+
+	v1, _ := pool.Acquire()
+	pool.Release(v1)
+	v1, _ = pool.Acquire()
+	pool.Close(v1)
+	v2, _ := pool.Acquire()
+	pool.Release(v2)
+	pool.EvictWhere(func(PoolObject) bool { return true })
+	v3, _ := pool.Acquire()
+	pool.Release(v3)
+	setObjectCreateTime(&v3, aged(time.Hour))
+	pool.Acquire()
+
+	want := []struct {
+		kind EventType
+		id   uint64
+	}{
+		{EventCreated, v1.ID()}, {EventAcquired, v1.ID()}, {EventReleased, v1.ID()},
+		{EventAcquired, v1.ID()}, {EventClosed, v1.ID()},
+		{EventCreated, v2.ID()}, {EventAcquired, v2.ID()}, {EventReleased, v2.ID()},
+		{EventEvicted, v2.ID()},
+		{EventCreated, v3.ID()}, {EventAcquired, v3.ID()}, {EventReleased, v3.ID()},
+		{EventExpired, v3.ID()}, {EventCreated, v3.ID() + 1}, {EventAcquired, v3.ID() + 1},
+	}
+	for i, w := range want {
+		select {
+		case e := <-events:
+			if e.Type != w.kind || e.ID != w.id {
+				t.Fatalf("[ERR] event %d: expected %v of %d, got %v of %d", i, w.kind, w.id, e.Type, e.ID)
+			}
+		default:
+			t.Fatalf("[ERR] event %d: expected %v of %d, got nothing", i, w.kind, w.id)
+		}
+	}
+	if pool.Stats().DroppedEvents != 0 {
+		t.Fatal("[ERR] expected no dropped events")
+	}
+
+	// a consumer not keeping up makes events drop
+	for i := 0; i < eventBuffer; i++ {
+		v, _ := pool.Acquire()
+		pool.Release(v)
+	}
+	if pool.Stats().DroppedEvents == 0 {
+		t.Fatal("[ERR] expected dropped events")
+	}
+}
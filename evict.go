@@ -0,0 +1,48 @@
+package pool
+
+// EvictByTag closes all idle objects tagged with tag, and returns how many
+// were closed. Objects in use with that tag are closed when released.
+func (p *GenericPool) EvictByTag(tag string) int {
+	return p.evict(func(poolObj PoolObject) bool {
+		return poolObj.Tag == tag
+	})
+}
+
+// EvictWhere closes all idle objects matching pred, and returns how many were
+// closed. Objects in use matching pred are closed when released. pred is
+// called with the lock held, so it must not call the pool. A panicking pred
+// counts as no match.
+func (p *GenericPool) EvictWhere(pred func(PoolObject) bool) int {
+	return p.evict(func(poolObj PoolObject) (match bool) {
+		p.protect("EvictWhere", func() error {
+			match = pred(poolObj)
+			return nil
+		})
+		return match
+	})
+}
+
+// evict closes the idle objects matching pred and returns their number.
+// Objects in use matching pred are closed on release.
+func (p *GenericPool) evict(pred func(PoolObject) bool) int {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return 0
+	}
+	evicted := 0
+	for _, poolObj := range p.drainIdle() {
+		if pred(poolObj) {
+			p.discardAs(poolObj, EventEvicted)
+			evicted++
+		} else if !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+	}
+	for _, c := range p.inUse {
+		if pred(c.poolObj) {
+			c.doomed = true
+		}
+	}
+	return evicted
+}
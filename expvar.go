@@ -0,0 +1,24 @@
+package pool
+
+import (
+	"expvar"
+	"sync"
+)
+
+// serializes PublishExpvar, so two pools can't both find a name free
+var expvarMu sync.Mutex
+
+// PublishExpvar publishes the pool's Stats as an expvar under name. The stats
+// are read on every expvar read, there is nothing to update.
+func (p *GenericPool) PublishExpvar(name string) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	// expvar.Publish panics on duplicates, report them instead
+	if expvar.Get(name) != nil {
+		return ErrNameTaken
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return p.Stats()
+	}))
+	return nil
+}
@@ -0,0 +1,82 @@
+package pool
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// expvars can't be unpublished, so every run needs fresh names
+var expvarSeq int32
+
+func expvarName(t *testing.T) string {
+	return fmt.Sprintf("%s_%d", t.Name(), atomic.AddInt32(&expvarSeq, 1))
+}
+
+func TestGenericPool_PublishExpvar(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	name := expvarName(t)
+	if err := pool.PublishExpvar(name); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.PublishExpvar(name); err != ErrNameTaken {
+		t.Fatalf("[ERR] expected ErrNameTaken, got %v", err)
+	}
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	var stats PoolStats
+	v := expvar.Get(name).String()
+	if err := json.Unmarshal([]byte(v), &stats); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats.Idle != 1 || stats.InUse != 1 || stats.Max != 3 {
+		t.Fatalf("[ERR] unexpected stats %s", v)
+	}
+	t.Log("[SUCC]", v)
+}
+
+func TestGenericPool_PublishExpvarConcurrent(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	name := expvarName(t)
+	var published int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch err := pool.PublishExpvar(name); err {
+			case nil:
+				atomic.AddInt32(&published, 1)
+			case ErrNameTaken:
+			default:
+				t.Error("[ERR]", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if published != 1 {
+		t.Fatalf("[ERR] expected the name published once, got %d", published)
+	}
+}
@@ -0,0 +1,95 @@
+package pool
+
+import (
+	"sync"
+	"time"
+)
+
+// FailoverPool hands out objects from a primary pool, and falls back to a
+// warm standby pool while the primary is unable to create objects.
+type FailoverPool struct {
+	sync.Mutex
+	primary       *GenericPool
+	secondary     *GenericPool
+	probeInterval time.Duration // how often to retry the primary after a failure
+	failedOver    bool          // primary is considered down
+	lastProbe     time.Time     // last time the primary was tried while down
+}
+
+func NewFailoverPool(primary, secondary *GenericPool, probeInterval time.Duration) (*FailoverPool, error) {
+	if primary == nil || secondary == nil || primary == secondary {
+		return nil, ErrInvalidConfig
+	}
+	return &FailoverPool{
+		primary:       primary,
+		secondary:     secondary,
+		probeInterval: probeInterval,
+	}, nil
+}
+
+// acquire object from primary pool, or from secondary pool if primary failed
+func (f *FailoverPool) Acquire() (PoolObject, error) {
+	if f.tryPrimary() {
+		poolObj, err := f.primary.Acquire()
+		f.Lock()
+		if err != nil && !f.failedOver {
+			// just failed, next probe is due after a full interval
+			f.lastProbe = time.Now()
+		}
+		f.failedOver = err != nil
+		f.Unlock()
+		if err == nil {
+			return poolObj, nil
+		}
+	}
+	return f.secondary.Acquire()
+}
+
+// tryPrimary reports whether the next acquire should go to the primary pool,
+// which is the case while it is healthy or when it is due for a probe. Only
+// one caller per interval gets to probe a failed primary.
+func (f *FailoverPool) tryPrimary() bool {
+	f.Lock()
+	defer f.Unlock()
+	if !f.failedOver {
+		return true
+	}
+	if time.Since(f.lastProbe) < f.probeInterval {
+		return false
+	}
+	f.lastProbe = time.Now()
+	return true
+}
+
+// release object into the pool it was acquired from
+func (f *FailoverPool) Release(poolObj PoolObject) error {
+	return f.origin(poolObj).Release(poolObj)
+}
+
+// close or delete object
+func (f *FailoverPool) Close(poolObj PoolObject) error {
+	return f.origin(poolObj).Close(poolObj)
+}
+
+func (f *FailoverPool) origin(poolObj PoolObject) *GenericPool {
+	if poolObj.pool == f.secondary {
+		return f.secondary
+	}
+	return f.primary
+}
+
+// shutdown both primary and secondary pool
+func (f *FailoverPool) Shutdown() error {
+	err := f.primary.Shutdown()
+	if err2 := f.secondary.Shutdown(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// whether acquires are currently served by the secondary pool
+func (f *FailoverPool) IsFailedOver() bool {
+	f.Lock()
+	defer f.Unlock()
+	return f.failedOver
+}
@@ -0,0 +1,79 @@
+package pool
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailoverPool_Acquire(t *testing.T) {
+	var primaryDown int32
+	primary, err := NewGenericPool(&PoolConfig{
+		Min: 1,
+		Max: 2,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.LoadInt32(&primaryDown) == 1 {
+				return nil, errors.New("primary down")
+			}
+			return "primary", nil
+		},
+		CloseFunc: closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	secondary, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: func() (interface{}, error) { return "secondary", nil },
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool, err := NewFailoverPool(primary, secondary, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	// the idle primary object is handed out first
+	v1, err := pool.Acquire()
+	if err != nil || v1.Object != "primary" {
+		t.Fatalf("[ERR] expected primary object, got %v %v", v1.Object, err)
+	}
+
+	// primary can not create a second object, so secondary takes over
+	atomic.StoreInt32(&primaryDown, 1)
+	v2, err := pool.Acquire()
+	if err != nil || v2.Object != "secondary" {
+		t.Fatalf("[ERR] expected secondary object, got %v %v", v2.Object, err)
+	}
+	if !pool.IsFailedOver() {
+		t.Fatal("[ERR] expected pool to be failed over")
+	}
+	if err := pool.Release(v2); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if secondary.Len() != 1 {
+		t.Fatalf("[ERR] expected object released to secondary, len %d", secondary.Len())
+	}
+
+	// primary recovered, but is not probed until the interval elapsed
+	atomic.StoreInt32(&primaryDown, 0)
+	v3, err := pool.Acquire()
+	if err != nil || v3.Object != "secondary" {
+		t.Fatalf("[ERR] expected secondary object, got %v %v", v3.Object, err)
+	}
+	pool.Release(v3)
+
+	time.Sleep(60 * time.Millisecond)
+	v4, err := pool.Acquire()
+	if err != nil || v4.Object != "primary" {
+		t.Fatalf("[ERR] expected failback to primary, got %v %v", v4.Object, err)
+	}
+	if pool.IsFailedOver() {
+		t.Fatal("[ERR] expected pool to fail back to primary")
+	}
+	t.Log("[SUCC]", primary.Len(), secondary.Len())
+}
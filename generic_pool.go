@@ -1,21 +1,138 @@
 package pool
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
-	"fmt"
 )
 
 var (
 	ErrInvalidConfig = errors.New("invalid pool config")
 	ErrPoolClosed    = errors.New("pool is closed")
 	ErrFactoryFunc   = errors.New("factory func err")
+	ErrNotInUse      = errors.New("object is not in use")
+	ErrTypeMismatch  = errors.New("factory returned object of unexpected type")
+	ErrCallbackPanic = errors.New("callback panicked")
+	ErrPoolPaused    = errors.New("pool is paused")
+	ErrPoolExhausted = errors.New("pool is exhausted")
+	ErrNameTaken     = errors.New("expvar name is already taken")
+	ErrInconsistent  = errors.New("pool accounting is inconsistent")
+	ErrRegistryFull  = errors.New("pool registry is full")
+	ErrPoolDraining  = errors.New("pool is draining")
+	ErrWrongPool     = errors.New("object was acquired from another pool")
+
+	ErrDependencyUnavailable = errors.New("dependency is unavailable")
+	ErrAcquireCancelled      = errors.New("acquire was cancelled")
+	ErrObjectInvalid         = errors.New("object failed validation")
+
+	// ErrObjectIdle is returned closing an object which is idle in the pool,
+	// and matches ErrNotInUse in errors.Is.
+	ErrObjectIdle = fmt.Errorf("%w: object is idle, use EvictWhere", ErrNotInUse)
+)
+
+const (
+	initAttemptFactor = 3                    // factory attempts per object when filling to minCap
+	initRetryDelay    = 5 * time.Millisecond // delay after a failed attempt when filling
+
+	minMonitorInterval = time.Millisecond // shortest tick of background loops
+	reclaimedRetention = 10 * time.Minute // how long a reclaimed object may still be released
+
+	streamRetryMin = 10 * time.Millisecond // first backoff of Stream after a failed acquire
+	streamRetryMax = time.Second           // longest backoff of Stream
+
+	releaseRetryDelay = 100 * time.Microsecond // first backoff of ReleaseRetries, doubled per retry
+	releaseRetryMax   = time.Millisecond       // longest backoff of ReleaseRetries
+	maxReleaseRetries = 10                     // most ReleaseRetries, bounding how long Release blocks
+
+	defaultMemoryCheckInterval = time.Second // how often to read memory stats for MemoryPressureThreshold
+	defaultOverflowIdleTimeout = time.Second // how long overflow objects may stay idle
+
+	minRetryAfter = time.Millisecond // least RetryAfter of an ExhaustedError
+
+	postCreateAttempts = 3 // factory calls per object when PostCreateFunc fails
+
+	saturationQuiet = 100 * time.Millisecond // OnSaturated is not fired again this soon after OnDesaturated
 )
 
 type FactoryFunc func() (interface{}, error)
 type CloseFunc func(interface{}) error
 
+// ResetFunc clears an object's state before it is pooled again.
+type ResetFunc func(interface{})
+
+// RecycleFunc hands an object leaving the pool back to where it came from,
+// such as a sync.Pool, rather than destroying it.
+type RecycleFunc func(interface{})
+
+// TaggedFactoryFunc creates an object together with a tag, such as the host
+// it is connected to.
+type TaggedFactoryFunc func() (obj interface{}, tag string, err error)
+
+// StatefulFactoryFunc creates an object knowing the current pool stats, such as
+// to balance objects across backends.
+type StatefulFactoryFunc func(stats PoolStats) (interface{}, error)
+
+// ContextFactoryFunc creates an object, giving up when ctx is done. If it
+// returns a partial object along with a context error, the object is closed.
+type ContextFactoryFunc func(ctx context.Context) (interface{}, error)
+
+// LessFunc reports whether idle object a should be handed out before b.
+type LessFunc func(a, b PoolObject) bool
+
+// Ordering decides which idle object an acquire gets.
+type Ordering int
+
+const (
+	// OrderFIFO hands out the object released first.
+	OrderFIFO Ordering = iota
+	// OrderLRU hands out the object with the oldest LastUsed, so all
+	// objects are kept warm, and dead ones are found sooner. It keeps the
+	// idle objects in a heap, like LessFunc.
+	OrderLRU
+)
+
+// lessLRU orders idle objects for OrderLRU
+func lessLRU(a, b PoolObject) bool {
+	return a.LastUsed < b.LastUsed
+}
+
+// InitFailurePolicy decides what filling the pool up to Min does when the
+// factory fails.
+type InitFailurePolicy int
+
+const (
+	// InitContinue skips the failed attempt and goes on filling, giving up
+	// after Min*3 factory calls in total.
+	InitContinue InitFailurePolicy = iota
+	// InitFailFast gives up at the first failure.
+	InitFailFast
+	// InitRetrySlot retries the failed object with a doubling delay, giving
+	// up once one object failed 3 times in a row.
+	InitRetrySlot
+)
+
+// PanicHandler is called with the recovered value when the user callback
+// named hook panics. The pool keeps working afterwards.
+type PanicHandler func(recovered interface{}, hook string)
+
+// Logger reports errors and warnings of the pool.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// stdoutLogger is the default Logger
+type stdoutLogger struct{}
+
+func (stdoutLogger) Printf(format string, v ...interface{}) {
+	fmt.Printf(format+"\n", v...)
+}
+
 type Pool interface {
 	Acquire() (interface{}, error) // acquire object from pool
 	Release(interface{}) error     // release object from pool
@@ -29,156 +146,2189 @@ type PoolConfig struct {
 	LiftTime    time.Duration // object's life tile
 	FactoryFunc FactoryFunc   // function to new object
 	CloseFunc   CloseFunc     // function to close or delete object
+
+	// PostCreateFunc is called once on every new object, to warm it up. If
+	// it fails, the object is closed and another one is created instead.
+	PostCreateFunc func(interface{}) error
+
+	// ValidateFunc checks that an object is still usable, such as by
+	// pinging a connection. It is called by PreflightCheck.
+	ValidateFunc func(interface{}) error
+
+	// KeepOnError reports whether an object released by ReleaseWithError may
+	// be pooled again despite the error. Without it, such objects are closed.
+	KeepOnError func(error) bool
+
+	// ResetFunc is called on every released object before it is pooled.
+	// Without it, AutoReset calls the object's own Reset method, if it has
+	// one.
+	ResetFunc ResetFunc
+	AutoReset bool
+
+	// MaxBytesPerObject retires objects on release once RecordBytes counted
+	// this many bytes transferred through them. 0 disables it.
+	MaxBytesPerObject int64
+
+	// DeferReset makes Release hand objects to a background worker for the
+	// reset, instead of resetting them itself. They are pooled once reset, so
+	// acquires only get reset objects.
+	DeferReset bool
+
+	// RecycleFunc replaces CloseFunc when set. Wherever the pool would close
+	// an object, it is given to RecycleFunc instead. Either way the object
+	// leaves the pool and stops counting against Max.
+	RecycleFunc RecycleFunc
+
+	// SerialFactory never calls the factory concurrently, for factories which
+	// aren't safe for that. Acquires of idle objects are not held up by it.
+	SerialFactory bool
+
+	// FactoryLimiter, if set, bounds the factory calls in flight across all
+	// pools sharing it. Acquires of idle objects are not held up by it.
+	FactoryLimiter *Limiter
+
+	// MinCreateInterval spaces out factory calls by at least this long, so
+	// a herd of cold acquires doesn't slam the backend. Acquires of idle
+	// objects are not held up by it.
+	MinCreateInterval time.Duration
+
+	// DedupeCreation makes an acquire finding no idle object wait, rather
+	// than create another object, while one is already being created. A
+	// burst of cold acquires then reuses objects as they are released,
+	// instead of creating one each.
+	DedupeCreation bool
+
+	// HedgedAcquire makes an acquire which has to create an object also wait
+	// for one to be released, and take whichever comes first. The object
+	// created too late is pooled. It has no effect with LessFunc.
+	HedgedAcquire bool
+
+	// ParallelInit creates the Min objects with up to this many factory
+	// calls at a time, rather than one by one.
+	ParallelInit int
+
+	// InitFailurePolicy decides how filling up to Min handles factory
+	// errors, InitContinue by default. Creating the pool fails with
+	// ErrFactoryFunc if Min isn't reached. LazyInit always continues.
+	InitFailurePolicy InitFailurePolicy
+
+	// LazyInit makes the constructor return right away and create the Min
+	// objects in the background. Use WaitReady to wait for them.
+	LazyInit bool
+
+	// SyncInit is how many of the Min objects the constructor creates, Min
+	// if 0. The others are created in the background as with LazyInit, so
+	// the pool can serve right away and warm up meanwhile.
+	SyncInit int
+
+	// RefreshOnRelease restarts an object's LiftTime every time it is
+	// released, so only objects left idle for LiftTime expire.
+	RefreshOnRelease bool
+
+	// CreateAheadFactor makes acquire top up idle objects in the background
+	// to ceil(factor * objects in use), bounded by Max. 0 disables it.
+	CreateAheadFactor float64
+
+	// MaxCheckoutTime reports objects held longer than this duration. With
+	// ForceReclaim set, such objects are also closed so they stop counting
+	// against Max; releasing them later is a no-op.
+	MaxCheckoutTime time.Duration
+	ForceReclaim    bool
+
+	// StrictMode panics on API misuse, such as releasing an object twice,
+	// instead of returning an error. Meant for catching bugs in tests.
+	StrictMode bool
+
+	// FailWhenPaused makes Acquire return ErrPoolPaused while the pool is
+	// paused, rather than blocking until it is resumed.
+	FailWhenPaused bool
+
+	// StrictFIFO hands out objects strictly in the order they became idle,
+	// released ones going to the tail. Acquires take turns, so concurrent
+	// ones can't overtake each other. It can't be combined with LessFunc or
+	// HedgedAcquire.
+	StrictFIFO bool
+
+	// LessFunc keeps idle objects in a heap, so acquire hands out the best
+	// one instead of the one idle for the longest time.
+	LessFunc LessFunc
+
+	// Ordering decides which idle object an acquire gets, OrderFIFO by
+	// default. It can't be combined with LessFunc.
+	Ordering Ordering
+
+	// TaggedFactoryFunc replaces FactoryFunc when set, tagging every object
+	// so it can be evicted by EvictByTag.
+	TaggedFactoryFunc TaggedFactoryFunc
+
+	// TagLifeTimes overrides LiftTime for objects with the given tags, so
+	// connections to some backends can be rotated faster than others.
+	TagLifeTimes map[string]time.Duration
+
+	// FactoryFuncStateful replaces FactoryFunc when set, getting a snapshot of
+	// the pool stats on every call. The object being created is counted in
+	// Total.
+	FactoryFuncStateful StatefulFactoryFunc
+
+	// FactoryFuncCtx replaces FactoryFunc when set, getting the context of
+	// the acquire creating the object. Objects the pool creates on its own,
+	// ahead of acquires or to replace expiring ones, get a context cancelled
+	// on shutdown. Objects created after shutdown anyway are closed.
+	FactoryFuncCtx ContextFactoryFunc
+
+	// FallbackFactoryFunc creates the object when the factory fails, such as
+	// a degraded local object while the backend is down. Such objects are
+	// marked Fallback.
+	FallbackFactoryFunc FactoryFunc
+
+	// MemoryPressureThreshold makes the pool close idle objects down to Min
+	// whenever the heap in use exceeds this many bytes. It is checked every
+	// MemoryCheckInterval, 1s by default. 0 disables it.
+	MemoryPressureThreshold uint64
+	MemoryCheckInterval     time.Duration
+
+	// RegisterWithDefault adds the pool to DefaultRegistry until it is shut
+	// down. Creating the pool fails with ErrRegistryFull if it has no room.
+	RegisterWithDefault bool
+
+	// AutoShutdownAfter shuts the pool down once nothing has been acquired
+	// for this long and no object is in use, freeing all objects. Acquires
+	// fail with ErrPoolClosed afterwards. 0 disables it.
+	AutoShutdownAfter time.Duration
+
+	// SlowAcquireThreshold makes acquires waiting longer than this log a
+	// warning with the wait and the current utilization. 0 disables it.
+	SlowAcquireThreshold time.Duration
+
+	// ReadyFunc is consulted before creating an object. While it returns
+	// false, acquires needing a new object fail with ErrDependencyUnavailable
+	// instead of calling the factory, idle objects are still handed out.
+	ReadyFunc func() bool
+
+	// ReplaceBefore makes the pool replace idle objects this long before they
+	// reach LiftTime, so acquires don't run into just expired objects.
+	ReplaceBefore time.Duration
+
+	// OverflowMax lets acquires on a pool at Max take objects from an
+	// overflow pool of up to OverflowMax more objects, rather than wait. It
+	// is created on the first such acquire. Its objects are closed once idle
+	// for OverflowIdleTimeout, 1s by default.
+	OverflowMax         int
+	OverflowIdleTimeout time.Duration
+
+	// Parent is a larger pool backing this one. An acquire on this pool at
+	// Max with no idle object borrows from Parent, without waiting, before
+	// it waits here. On release, a borrowed object is kept by this pool if
+	// it has room, and given back to Parent otherwise.
+	Parent *GenericPool
+
+	// MinIdleBeforeEvict spares objects idle for less than this duration
+	// when the pool scales down, so a brief dip in load doesn't close
+	// objects which are needed again right after.
+	MinIdleBeforeEvict time.Duration
+
+	// Tracer records a span for every Acquire, Release and Close.
+	Tracer Tracer
+
+	// OnSaturated is called when an acquire first has to wait because the
+	// pool is at Max, and OnDesaturated once no acquire is waiting anymore.
+	// They are called without the lock held, and must not block.
+	OnSaturated   func()
+	OnDesaturated func()
+
+	// OnDrainProgress is called for every object closed by Shutdown,
+	// ShutdownContext and CloseIdle, with the objects closed so far out of
+	// those there were to close. It is called with the lock held, and must
+	// not call the pool.
+	OnDrainProgress func(closed, total int)
+
+	// OnMinReached is called when the pool is back at Min objects, after
+	// having dropped below, such as when a backend recovered. It is called
+	// in a goroutine of its own.
+	OnMinReached func()
+
+	// Clock is where the pool reads the time from, for object lifetimes,
+	// idle and checkout times, and stats. It defaults to the system clock.
+	// Background checks still run, and acquires still time out, in real
+	// time.
+	Clock Clock
+
+	// ErrorHistorySize is how many of the last factory errors RecentErrors
+	// returns, 16 by default.
+	ErrorHistorySize int
+
+	// ReleaseRetries is how many times Release of a surplus object waits a
+	// little for the pool to get back within Max before it closes the
+	// object. Objects are only surplus after Resize or Reconfigure lowered
+	// Max, otherwise released objects always fit. A slot frees up when
+	// another object is closed; an acquire doesn't free one, as the object
+	// it takes still counts against Max. 0 closes surplus objects right
+	// away, and at most 10 are allowed.
+	ReleaseRetries int
+
+	Logger       Logger       // defaults to printing to stdout
+	PanicHandler PanicHandler // defaults to logging the panic
 }
 
 type PoolObject struct {
-	CreateTime int64
+	CreateTime int64 // unix time in nanoseconds
+	LastUsed   int64 // unix time in nanoseconds of the last release, CreateTime before
 	Object     interface{}
+	Tag        string            // tag given by TaggedFactoryFunc
+	Fallback   bool              // created by FallbackFactoryFunc
+	Meta       map[string]string // metadata kept with the object, shared by all copies
+	LastError  error             // error of the last ReleaseWithError, nil after a clean Release
+	pool       *GenericPool      // pool which created the object
+	id         uint64            // unique id within the pool
+	gen        uint64            // pool generation the object was created in
+	idleSince  int64             // unix time in nanoseconds the object was last pooled
+	bytes      int64             // transferred before the current checkout
+	uses       int               // checkouts before the current one
+	held       time.Duration     // checked out before the current checkout
+}
+
+// ID returns the sequence number of the object within its pool. Objects are
+// numbered from 1 in the order they are created, regardless of the factory.
+func (o PoolObject) ID() uint64 {
+	return o.id
+}
+
+// Clone returns a copy of the object's metadata, which can be changed without
+// touching the pool's copy. The object itself is not copied.
+func (o PoolObject) Clone() PoolObject {
+	clone := o
+	if o.Meta != nil {
+		clone.Meta = make(map[string]string, len(o.Meta))
+		for k, v := range o.Meta {
+			clone.Meta[k] = v
+		}
+	}
+	return clone
+}
+
+// idAssigner hands out object ids in creation order
+type idAssigner struct {
+	last uint64 // accessed atomically
+}
+
+func (a *idAssigner) next() uint64 {
+	return atomic.AddUint64(&a.last, 1)
+}
+
+// checked out object and the time it was acquired
+type checkout struct {
+	poolObj  PoolObject
+	since    time.Time
+	reported bool  // already counted as overdue
+	doomed   bool  // close instead of pooling on release
+	reset    bool  // already reset by ReleaseContext
+	bytes    int64 // transferred through the object over its life, by RecordBytes
 }
 
 type GenericPool struct {
 	sync.Mutex
-	pool        chan PoolObject
-	maxCap      int               // max capacity of pool
-	minCap      int               // min capacity of pool
-	curNum      int               // current object number in pool
-	closed      bool
-	maxLifeTime time.Duration
-	factoryFunc FactoryFunc
-	closeFunc   CloseFunc
+	pool     chan PoolObject
+	maxCap   int // max capacity of pool
+	minCap   int // min capacity of pool
+	warmCap  int // objects init fills the pool with, minCap unless warmed from a state
+	curNum   int // current object number in pool
+	closed   bool
+	killed   bool         // objects in use are closed on release
+	draining bool         // ShutdownContext waits for objects in use
+	cfg      atomic.Value // *settings, replaced by Reconfigure
+
+	createAheadFactor float64
+	creatingAhead     bool // a create-ahead goroutine is running
+
+	ids             idAssigner
+	createCount     int64                // factory calls, accessed atomically
+	createNanos     int64                // time spent in factory calls, accessed atomically
+	acquireFailures int64                // acquires failed for other reasons than ctx or shutdown, accessed atomically
+	events          atomic.Value         // chan PoolEvent, once Events is called
+	droppedEvents   int64                // events not sent as the channel was full, accessed atomically
+	inUse           map[uint64]*checkout // checked out objects by id
+	inUseCount      int64                // len(inUse), accessed atomically
+	holdCount       int64                // objects checked in
+	holdNanos       int64                // time the checked in objects were held
+	maxCount        int64                // maxCap, accessed atomically
+	reclaimed       map[uint64]time.Time // force reclaimed objects not yet released, by reclaim time
+	maxCheckoutTime time.Duration
+	forceReclaim    bool
+	overdueCount    int
+	done            chan struct{}      // closed on shutdown to stop background loops
+	bgCtx           context.Context    // of background creations, cancelled on shutdown
+	bgCancel        context.CancelFunc // cancels bgCtx
+	generation      uint64             // bumped by Recycle and config changes, accessed atomically
+	strictMode      bool
+	objType         reflect.Type  // type every object must have, if set
+	signal          chan struct{} // closed and replaced to wake up waiters
+	logger          Logger
+	panicHandler    PanicHandler
+	paused          bool
+	failWhenPaused  bool
+	sorted          *idleHeap     // idle objects, instead of the channel, if LessFunc is set
+	waiters         int           // acquires blocked waiting for an object
+	cancels         uint64        // bumped by CancelWaiters, accessed atomically
+	cancelErr       error         // returned to the waiters of the last CancelWaiters
+	sharedMu        sync.Mutex    // serializes AcquireShared and ReleaseShared
+	shared          *sharedObject // object currently lent by AcquireShared
+	tracer          Tracer
+	onSaturated     func()
+	onDesaturated   func()
+	onDrainProgress func(closed, total int)
+	onMinReached    func()
+	validateFunc    func(interface{}) error
+	belowMin        bool      // dropped below minCap, OnMinReached not fired yet
+	saturated       bool      // OnSaturated fired, OnDesaturated not yet
+	desaturatedAt   time.Time // last time OnDesaturated fired
+
+	keepOnError       func(error) bool
+	dedupe            bool // wait for the object in creation rather than create another
+	creating          int  // objects being created by createReserved
+	serialFactory     bool
+	factoryMu         sync.Mutex    // serializes factory calls if serialFactory
+	limiter           *Limiter      // shared with other pools, may be nil
+	createTurn        chan struct{} // held while waiting out minCreateInterval, if set
+	minCreateInterval time.Duration
+	lastCreate        time.Time // last factory call, guarded by createTurn
+
+	hedge        bool          // race creation against releases
+	fifoTurn     chan struct{} // held by the acquire whose turn it is, if StrictFIFO
+	parallelInit int
+	initPolicy   InitFailurePolicy
+	lazyInit     bool
+	syncInit     int           // objects created by the constructor, the rest lazily
+	ready        chan struct{} // closed once the pool has been filled to minCap
+	readyErr     error         // why filling the pool in the background failed
+
+	memoryThreshold     uint64
+	memoryCheckInterval time.Duration
+	minIdleBeforeEvict  time.Duration
+	readMemStats        func(*runtime.MemStats) // replaced in tests to fake memory pressure
+
+	registry      *Registry     // registry the pool is in, if any
+	replaceBefore time.Duration // replace idle objects this long before they expire
+	readyFunc     func() bool   // whether objects can be created
+	slowAcquire   time.Duration // log acquires waiting longer than this
+	autoShutdown  time.Duration // shut down after this long without acquires
+	lastAcquire   time.Time     // when an object was last checked out
+	clock         Clock
+	outcomes      factoryOutcomes                    // recent factory calls, for Health and RecentErrors
+	errHistory    int                                // factory errors kept for RecentErrors
+	relRetries    int                                // surplus releases wait this often for a slot
+	releaseWait   func(chan struct{}, time.Duration) // waits between ReleaseRetries, replaced in tests
+
+	middlewares []Middleware
+	chain       atomic.Value // AcquireFunc wrapping acquire in the middlewares
+
+	capacityWaiters []chan struct{} // closed by notifyCapacity
+	tagStats        map[string]TagStat
+
+	resetQueue chan PoolObject // released objects awaiting the reset worker, if DeferReset
+	maxBytes   int64           // retire objects after transferring this many bytes
+	totalBytes int64           // recorded by RecordBytes, ever
+	resetting  int             // objects queued or being reset
+
+	overflowMax         int
+	overflowIdleTimeout time.Duration
+	overflow            *GenericPool // created on demand if overflowMax > 0
+	parent              *GenericPool // pool this one is the overflow of
+	backing             *GenericPool // Parent, borrowed from when at maxCap
 }
 
 func NewGenericPool(config *PoolConfig) (*GenericPool, error) {
-	if config.Max <= 0 || config.Min > config.Max {
+	p, err := newGenericPool(config)
+	if err != nil {
+		return nil, err
+	}
+	return p, p.init()
+}
+
+// NewGenericPoolTyped is like NewGenericPool, but checks that every object the
+// factory creates has the same concrete type as sample. Objects of any other
+// type are dropped, and the acquire fails with ErrTypeMismatch.
+func NewGenericPoolTyped(config *PoolConfig, sample interface{}) (*GenericPool, error) {
+	if sample == nil {
 		return nil, ErrInvalidConfig
 	}
-	p := &GenericPool{
-		maxCap:      config.Max,
-		minCap:      config.Min,
-		maxLifeTime: config.LiftTime,
-		factoryFunc: config.FactoryFunc,
-		closeFunc:   config.CloseFunc,
-		pool:        make(chan PoolObject, config.Max),
+	p, err := newGenericPool(config)
+	if err != nil {
+		return nil, err
 	}
+	p.objType = reflect.TypeOf(sample)
+	return p, p.init()
+}
 
-	nowTime := time.Now().Unix()
-	for i := 0; i < p.minCap; i++ {
-		obj, err := p.factoryFunc()
-		if err != nil {
-			continue
+// NewGenericPoolWith is like NewGenericPool, but seeds the pool with initial
+// objects first. The factory only creates what is missing up to Min. More
+// initial objects than Max, or nil ones, fail with ErrInvalidConfig.
+func NewGenericPoolWith(config *PoolConfig, initial []interface{}) (*GenericPool, error) {
+	if err := ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	if len(initial) > config.Max {
+		return nil, ErrInvalidConfig
+	}
+	for i, obj := range initial {
+		if obj == nil {
+			return nil, fmt.Errorf("%w: initial object %d is nil", ErrInvalidConfig, i)
 		}
+	}
+	p, err := newGenericPool(config)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range initial {
 		p.curNum++
-		poolObj := PoolObject{CreateTime: nowTime, Object: obj}
-		p.pool <- poolObj
+		p.putIdle(p.wrap(obj))
+	}
+	return p, p.init()
+}
+
+func newGenericPool(config *PoolConfig) (*GenericPool, error) {
+	if err := ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	clock := config.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	p := &GenericPool{
+		maxCap:  config.Max,
+		minCap:  config.Min,
+		warmCap: config.Min,
+		pool:    make(chan PoolObject, config.Max),
+
+		maxCount:          int64(config.Max),
+		createAheadFactor: config.CreateAheadFactor,
+		inUse:             make(map[uint64]*checkout),
+		tagStats:          make(map[string]TagStat),
+		reclaimed:         make(map[uint64]time.Time),
+		maxCheckoutTime:   config.MaxCheckoutTime,
+		forceReclaim:      config.ForceReclaim,
+		done:              make(chan struct{}),
+		strictMode:        config.StrictMode,
+		signal:            make(chan struct{}),
+		logger:            config.Logger,
+		panicHandler:      config.PanicHandler,
+		failWhenPaused:    config.FailWhenPaused,
+		tracer:            config.Tracer,
+		onSaturated:       config.OnSaturated,
+		onDesaturated:     config.OnDesaturated,
+		onDrainProgress:   config.OnDrainProgress,
+		onMinReached:      config.OnMinReached,
+		validateFunc:      config.ValidateFunc,
+
+		keepOnError:       config.KeepOnError,
+		dedupe:            config.DedupeCreation,
+		serialFactory:     config.SerialFactory,
+		limiter:           config.FactoryLimiter,
+		minCreateInterval: config.MinCreateInterval,
+
+		hedge:        config.HedgedAcquire,
+		parallelInit: config.ParallelInit,
+		initPolicy:   config.InitFailurePolicy,
+		lazyInit:     config.LazyInit,
+		syncInit:     config.SyncInit,
+		ready:        make(chan struct{}),
+
+		memoryThreshold:     config.MemoryPressureThreshold,
+		memoryCheckInterval: config.MemoryCheckInterval,
+		minIdleBeforeEvict:  config.MinIdleBeforeEvict,
+		readMemStats:        runtime.ReadMemStats,
+
+		replaceBefore:       config.ReplaceBefore,
+		maxBytes:            config.MaxBytesPerObject,
+		readyFunc:           config.ReadyFunc,
+		slowAcquire:         config.SlowAcquireThreshold,
+		autoShutdown:        config.AutoShutdownAfter,
+		clock:               clock,
+		errHistory:          config.ErrorHistorySize,
+		relRetries:          config.ReleaseRetries,
+		releaseWait:         waitSignal,
+		lastAcquire:         clock.Now(),
+		overflowMax:         config.OverflowMax,
+		overflowIdleTimeout: config.OverflowIdleTimeout,
+		backing:             config.Parent,
+	}
+	if p.memoryCheckInterval <= 0 {
+		p.memoryCheckInterval = defaultMemoryCheckInterval
 	}
-	if p.curNum == 0 {
-		return p, ErrFactoryFunc
+	if p.overflowIdleTimeout <= 0 {
+		p.overflowIdleTimeout = defaultOverflowIdleTimeout
+	}
+	if p.errHistory <= 0 {
+		p.errHistory = defaultErrorHistorySize
+	}
+	if less := config.idleLess(); less != nil {
+		p.sorted = &idleHeap{less: less}
+	}
+	p.bgCtx, p.bgCancel = context.WithCancel(context.Background())
+	if config.StrictFIFO {
+		p.fifoTurn = make(chan struct{}, 1)
+	}
+	if config.MinCreateInterval > 0 {
+		p.createTurn = make(chan struct{}, 1)
+	}
+	if config.DeferReset {
+		p.resetQueue = make(chan PoolObject, config.Max)
+	}
+	if config.RegisterWithDefault {
+		p.registry = DefaultRegistry
+	}
+	p.cfg.Store(newSettings(config))
+	if p.logger == nil {
+		p.logger = stdoutLogger{}
+	}
+	if p.panicHandler == nil {
+		p.panicHandler = func(recovered interface{}, hook string) {
+			p.logger.Printf("[POOL][ERROR] %s panicked: %v", hook, recovered)
+		}
 	}
 	return p, nil
 }
 
+// init fills the pool up to warmCap and starts the background loops.
+func (p *GenericPool) init() error {
+	if p.registry != nil {
+		if err := p.registry.Register(p); err != nil {
+			return err
+		}
+	}
+	if p.lazyInit {
+		go p.fillLazily()
+		p.startMonitors()
+		return nil
+	}
+	target := p.warmCap
+	if p.syncInit > 0 && p.syncInit < target {
+		target = p.syncInit
+	}
+	attempts, lastErr := p.fill(target)
+	if p.curNum < target {
+		err := fmt.Errorf("%w: created %d of %d objects in %d attempts, last error: %w",
+			ErrFactoryFunc, p.curNum, target, attempts, lastErr)
+		// callers drop a pool that failed to fill, don't leak what was created
+		for _, poolObj := range p.drainIdle() {
+			p.discard(poolObj)
+		}
+		if p.registry != nil {
+			p.registry.Deregister(p)
+		}
+		return err
+	}
+	if target < p.warmCap {
+		go p.fillLazily()
+	} else {
+		close(p.ready)
+	}
+	p.startMonitors()
+	return nil
+}
+
+// fill creates objects up to target for init, by parallelInit goroutines.
+// It gives up after target*initAttemptFactor factory calls.
+func (p *GenericPool) fill(target int) (attempts int, lastErr error) {
+	workers := p.parallelInit
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	stop := false // set once the policy gives up
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Lock()
+			defer p.Unlock()
+			failures := 0 // of the current object, for InitRetrySlot
+			for p.curNum < target && !stop &&
+				(p.initPolicy == InitRetrySlot || attempts < target*initAttemptFactor) {
+				attempts++
+				p.curNum++
+				p.Unlock()
+				poolObj, err := p.createObject()
+				p.Lock()
+				if err != nil {
+					p.curNum--
+					lastErr = err
+					failures++
+					delay := initRetryDelay
+					switch p.initPolicy {
+					case InitFailFast:
+						stop = true
+						continue
+					case InitRetrySlot:
+						if failures >= initAttemptFactor {
+							stop = true
+							continue
+						}
+						delay <<= failures - 1
+					}
+					p.Unlock()
+					time.Sleep(delay)
+					p.Lock()
+					continue
+				}
+				failures = 0
+				p.putIdle(poolObj)
+			}
+		}()
+	}
+	wg.Wait()
+	return attempts, lastErr
+}
+
+// startMonitors starts the configured background loops.
+func (p *GenericPool) startMonitors() {
+	if p.maxCheckoutTime > 0 {
+		go p.checkoutMonitor()
+	}
+	if p.memoryThreshold > 0 {
+		go p.memoryMonitor()
+	}
+	if p.replaceBefore > 0 {
+		go p.replaceMonitor()
+	}
+	if p.autoShutdown > 0 {
+		go p.inactivityMonitor()
+	}
+	if p.resetQueue != nil {
+		go p.resetWorker()
+	}
+}
+
 func (p *GenericPool) isLiftTimeOut(obj PoolObject) bool {
-	if int64(p.maxLifeTime) <= 0 {
+	maxLifeTime := p.settings().lifeTime(obj.Tag)
+	if int64(maxLifeTime) <= 0 {
 		// if object is invalid
 		return false
 	}
-	return obj.CreateTime+int64(p.maxLifeTime) <= time.Now().Unix()
+	return obj.CreateTime+int64(maxLifeTime) <= p.now().UnixNano()
 }
 
 func (p *GenericPool) Acquire() (poolObj PoolObject, err error) {
-	if p.closed {
-		return poolObj, ErrPoolClosed
+	return p.acquireFunc()(context.Background())
+}
+
+// AcquireContext is Acquire, giving up waiting, and creating the object by
+// FactoryFuncCtx, once ctx is done.
+func (p *GenericPool) AcquireContext(ctx context.Context) (PoolObject, error) {
+	return p.acquireFunc()(ctx)
+}
+
+// AcquireScoped acquires an object along with a function releasing it, for
+// callers to defer without keeping hold of the pool. Calls of release after
+// the first are no-ops. A failed release is logged, as nobody is there to
+// handle it.
+func (p *GenericPool) AcquireScoped() (poolObj PoolObject, release func(), err error) {
+	poolObj, err = p.Acquire()
+	if err != nil {
+		return poolObj, func() {}, err
+	}
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			if err := p.Release(poolObj); err != nil {
+				p.logger.Printf("[POOL][WARN] scoped release of object %d failed: %v", poolObj.ID(), err)
+			}
+		})
+	}
+	return poolObj, release, nil
+}
+
+// AcquireWatched is AcquireScoped, with a watchdog logging a warning and
+// counting the object in OverdueCount if it isn't released within maxHold.
+// Calling release stops the watchdog.
+func (p *GenericPool) AcquireWatched(maxHold time.Duration) (PoolObject, func(), error) {
+	poolObj, release, err := p.AcquireScoped()
+	if err != nil {
+		return poolObj, release, err
+	}
+	watchdog := time.AfterFunc(maxHold, func() {
+		p.Lock()
+		p.overdueCount++
+		p.Unlock()
+		p.logger.Printf("[POOL][WARN] object %d not released within %v.", poolObj.ID(), maxHold)
+	})
+	return poolObj, func() {
+		watchdog.Stop()
+		release()
+	}, nil
+}
+
+// acquire object from pool, waiting for one to be released if the pool is
+// exhausted until ctx is done
+func (p *GenericPool) acquire(ctx context.Context) (poolObj PoolObject, err error) {
+	// acquires queued for their turn are waiters for CancelWaiters too
+	cancels := atomic.LoadUint64(&p.cancels)
+	if p.fifoTurn != nil {
+		if err := p.waitTurn(ctx, cancels); err != nil {
+			return poolObj, err
+		}
+		defer func() { <-p.fifoTurn }()
 	}
+	var created bool
+	span := p.startSpan("pool.Acquire")
+	start := p.now()
+	defer func() {
+		wait := p.since(start)
+		if p.slowAcquire > 0 && wait > p.slowAcquire {
+			p.logger.Printf("[POOL][WARN] acquire waited %v, utilization %.2f.", wait, p.Utilization())
+		}
+		span.SetAttribute("pool.wait_duration", wait)
+		span.SetAttribute("pool.created", created)
+		endSpan(span, poolObj, err)
+	}()
 	for {
-		poolObj, err = p.getOrCreate()
+		poolObj, created, err = p.getOrCreate(ctx, cancels)
 		if err != nil {
-			fmt.Println("[POOL][ERROR] get or create object falied.")
+			if err != ctx.Err() {
+				p.logger.Printf("[POOL][ERROR] get or create object falied.")
+				if err != ErrPoolClosed {
+					atomic.AddInt64(&p.acquireFailures, 1)
+				}
+			}
 			return poolObj, err
 		}
-		// handle maxLifeTime
-		if p.isLiftTimeOut(poolObj) {
+		if poolObj.pool != p {
+			// lent by the overflow or Parent pool, which took care of it
+			return poolObj, nil
+		}
+		// handle maxLifeTime, or created before the last recycle
+		if !created && (p.isLiftTimeOut(poolObj) || p.isStale(poolObj)) {
+			p.Lock()
+			p.discardAs(poolObj, EventExpired)
+			p.Unlock()
 			continue
 		}
+		p.checkout(poolObj)
+		p.createAhead()
 		return poolObj, nil
 	}
 }
 
-func (p *GenericPool) getOrCreate() (poolObj PoolObject, err error) {
-	select {
-	case poolObj = <-p.pool:
-		return
-	default:
+// ExhaustedError is returned by acquires which don't wait on an exhausted
+// pool, with a hint when to retry. It matches ErrPoolExhausted in errors.Is.
+type ExhaustedError struct {
+	RetryAfter time.Duration // estimated time until an object is released
+}
+
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", ErrPoolExhausted, e.RetryAfter)
+}
+
+func (e *ExhaustedError) Unwrap() error {
+	return ErrPoolExhausted
+}
+
+// retryAfter estimates when the next object is released, from the average
+// time objects have been held so far, spread over the objects in use. Must be
+// called with the lock held.
+func (p *GenericPool) retryAfter() time.Duration {
+	if p.holdCount == 0 || len(p.inUse) == 0 {
+		return minRetryAfter
 	}
-	p.Lock()
-	if p.curNum >= p.maxCap {
-		poolObj = <-p.pool
-		p.Unlock()
-		return
+	estimate := time.Duration(p.holdNanos/p.holdCount) / time.Duration(len(p.inUse))
+	if estimate < minRetryAfter {
+		return minRetryAfter
 	}
-	// new an object
-	nowTime := time.Now().Unix()
-	obj, err := p.factoryFunc()
-	if err != nil {
+	return estimate
+}
+
+// AcquireOrCreate is like Acquire, but never blocks. It takes an idle object,
+// or creates one if the pool is below Max, and reports which one it did. A
+// full pool fails with an ExhaustedError, a paused one with ErrPoolPaused.
+func (p *GenericPool) AcquireOrCreate() (poolObj PoolObject, created bool, err error) {
+	for {
+		p.Lock()
+		if p.closed {
+			p.Unlock()
+			return poolObj, false, ErrPoolClosed
+		}
+		if p.draining {
+			p.Unlock()
+			return poolObj, false, ErrPoolDraining
+		}
+		if p.paused {
+			p.Unlock()
+			return poolObj, false, ErrPoolPaused
+		}
+		poolObj, ok := p.takeIdle()
+		if ok && (p.isLiftTimeOut(poolObj) || p.isStale(poolObj)) {
+			p.discardAs(poolObj, EventExpired)
+			p.Unlock()
+			continue
+		}
+		if !ok {
+			if p.curNum >= p.maxCap {
+				err := &ExhaustedError{RetryAfter: p.retryAfter()}
+				p.Unlock()
+				return poolObj, false, err
+			}
+			p.reserve()
+		}
 		p.Unlock()
-		return
+		if !ok {
+			if poolObj, err = p.createReserved(context.Background()); err != nil {
+				return poolObj, false, err
+			}
+		}
+		p.checkout(poolObj)
+		p.createAhead()
+		return poolObj, !ok, nil
 	}
-	p.curNum++
-	poolObj.CreateTime = nowTime
-	poolObj.Object = obj
-	//poolObj = PoolObject{CreateTime: nowTime, Object: obj}
-	p.Unlock()
-	return
 }
 
-// release object into pool
-func (p *GenericPool) Release(poolObj PoolObject) error {
+// AcquireByID acquires the idle object with the given ID, for sticky use of
+// the same object. It reports false without waiting if the object is in use,
+// or has been closed.
+func (p *GenericPool) AcquireByID(id uint64) (poolObj PoolObject, ok bool, err error) {
+	p.Lock()
 	if p.closed {
-		return ErrPoolClosed
+		p.Unlock()
+		return poolObj, false, ErrPoolClosed
 	}
-	if !p.isLiftTimeOut(poolObj) {
-		p.Lock()
-		p.pool <- poolObj
+	if p.draining {
 		p.Unlock()
+		return poolObj, false, ErrPoolDraining
 	}
-	return nil
+	if p.paused {
+		p.Unlock()
+		return poolObj, false, ErrPoolPaused
+	}
+	for _, idle := range p.drainIdle() {
+		if idle.id == id {
+			poolObj, ok = idle, true
+		} else if !p.putIdle(idle) {
+			p.discard(idle)
+		}
+	}
+	if ok && (p.isLiftTimeOut(poolObj) || p.isStale(poolObj)) {
+		p.discardAs(poolObj, EventExpired)
+		ok = false
+	}
+	p.Unlock()
+	if ok {
+		p.checkout(poolObj)
+	}
+	return poolObj, ok, nil
 }
 
-// close or delete object
-func (p *GenericPool) Close(poolObj PoolObject) error {
+// checkout records the object as in use
+func (p *GenericPool) checkout(poolObj PoolObject) {
 	p.Lock()
-	if err := p.closeFunc(poolObj.Object); err != nil {
-		p.Unlock()
-		return err
-	}
-	p.curNum--
+	p.inUse[poolObj.id] = &checkout{poolObj: poolObj, since: p.now(), bytes: poolObj.bytes}
+	p.lastAcquire = p.now()
+	atomic.AddInt64(&p.inUseCount, 1)
+	p.emit(EventAcquired, poolObj)
 	p.Unlock()
-	return nil
 }
 
-// shutdown current pool, and remove all object from that pool
-func (p *GenericPool) Shutdown() error {
-	if p.closed {
-		return ErrPoolClosed
+// checkin forgets an object in use, if it is. Must be called with the lock
+// held.
+func (p *GenericPool) checkin(id uint64) {
+	if c, ok := p.inUse[id]; ok {
+		p.holdCount++
+		p.holdNanos += int64(p.since(c.since))
+		delete(p.inUse, id)
+		atomic.AddInt64(&p.inUseCount, -1)
 	}
+}
+
+// waitTurn waits until it is the caller's turn to acquire with StrictFIFO, and
+// takes it. It fails if ctx is done, or CancelWaiters was called after cancels
+// was read. The caller counts as a waiter meanwhile.
+func (p *GenericPool) waitTurn(ctx context.Context, cancels uint64) error {
 	p.Lock()
-	close(p.pool)
-	for poolObj := range p.pool {
-		if err := p.closeFunc(poolObj.Object); err != nil {
+	p.waiters++
+	p.Unlock()
+	defer func() {
+		p.Lock()
+		p.waiters--
+		desaturated := p.desaturate()
+		p.Unlock()
+		if desaturated {
+			p.notify("OnDesaturated", p.onDesaturated)
+		}
+	}()
+	for {
+		p.Lock()
+		if atomic.LoadUint64(&p.cancels) != cancels {
+			err := p.cancelErr
 			p.Unlock()
 			return err
 		}
-		p.curNum--
+		signal := p.signal
+		p.Unlock()
+		select {
+		case p.fifoTurn <- struct{}{}:
+			return nil
+		case <-signal:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	p.closed = true
-	p.Unlock()
-	return nil
-}
-
-// object numbers in current pool
-func (p *GenericPool) Len() int {
-	return len(p.pool)
 }
 
-func (p *GenericPool) IsClosed() bool {
+// getOrCreate takes an idle object, or creates one if there is room, and
+// reports whether the object was just created. It fails if CancelWaiters was
+// called after cancels was read.
+func (p *GenericPool) getOrCreate(ctx context.Context, cancels uint64) (poolObj PoolObject, created bool, err error) {
+	for {
+		p.Lock()
+		if p.closed {
+			p.Unlock()
+			return poolObj, false, ErrPoolClosed
+		}
+		if p.draining {
+			p.Unlock()
+			return poolObj, false, ErrPoolDraining
+		}
+		if atomic.LoadUint64(&p.cancels) != cancels {
+			err := p.cancelErr
+			p.Unlock()
+			return poolObj, false, err
+		}
+		var idle chan PoolObject // stays nil while paused, so nothing is taken
+		if p.paused {
+			if p.failWhenPaused {
+				p.Unlock()
+				return poolObj, false, ErrPoolPaused
+			}
+		} else {
+			if poolObj, ok := p.takeIdle(); ok {
+				p.Unlock()
+				return poolObj, false, nil
+			}
+			if p.curNum < p.maxCap && !(p.dedupe && p.creating > 0) {
+				// reserve the slot, and new an object without holding the lock
+				p.reserve()
+				if p.hedge && p.sorted == nil {
+					idle, signal := p.pool, p.signal
+					p.waiters++
+					p.Unlock()
+					obj, created, ok, err := p.hedgedCreate(ctx, idle, signal)
+					if err != nil || ok {
+						return obj, created, err
+					}
+					continue
+				}
+				p.Unlock()
+				poolObj, err = p.createReserved(ctx)
+				return poolObj, err == nil, err
+			}
+			if lenders := p.lenders(); len(lenders) > 0 {
+				p.Unlock()
+				for _, lender := range lenders {
+					if poolObj, _, err := lender.AcquireOrCreate(); err == nil {
+						return poolObj, false, nil
+					}
+				}
+				p.Lock()
+				if p.closed || p.idleLen() > 0 || p.curNum < p.maxCap {
+					// changed while the lock was released, look again
+					p.Unlock()
+					continue
+				}
+			}
+			if p.sorted == nil {
+				idle = p.pool
+			}
+		}
+		signal := p.signal
+		p.waiters++
+		saturated := !p.paused && p.curNum >= p.maxCap && p.saturate()
+		p.Unlock()
+		if saturated {
+			p.notify("OnSaturated", p.onSaturated)
+		}
+
+		obj, ok, err := p.wait(ctx, idle, signal)
+		if err != nil || ok {
+			return obj, false, err
+		}
+	}
+}
+
+// hedgedCreate news an object in a reserved slot, while also waiting for one
+// to be released, and returns whichever comes first. A new object arriving
+// late is pooled. Like wait, it reports false if the caller should look again,
+// such as when waiters are signalled, and the caller must have counted itself
+// in waiters.
+func (p *GenericPool) hedgedCreate(ctx context.Context, idle chan PoolObject, signal chan struct{}) (poolObj PoolObject, created, ok bool, err error) {
+	type result struct {
+		poolObj PoolObject
+		err     error
+	}
+	results := make(chan result, 1)
+	go func() {
+		poolObj, err := p.createReserved(ctx)
+		results <- result{poolObj, err}
+	}()
+	select {
+	case r := <-results:
+		p.Lock()
+		p.waiters--
+		p.Unlock()
+		return r.poolObj, r.err == nil, true, r.err
+	case poolObj, ok = <-idle:
+	case <-signal:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.Lock()
+	p.waiters--
+	if ok && p.paused {
+		// released while paused, keep it for after Resume
+		ok = false
+		if p.closed || !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+	}
+	p.Unlock()
+
+	// the new object lost the race, or is still wanted after a retry
+	go func() {
+		r := <-results
+		if r.err != nil {
+			return
+		}
+		p.Lock()
+		defer p.Unlock()
+		if p.closed {
+			p.closeAs(r.poolObj, EventClosed)
+			p.curNum--
+		} else if !p.putIdle(r.poolObj) {
+			p.discard(r.poolObj)
+		}
+	}()
+	return poolObj, false, ok, err
+}
+
+// reserve counts a slot for an object about to be created, by createReserved
+// or otherwise.
+// Must be called with the lock held.
+func (p *GenericPool) reserve() {
+	p.curNum++
+	p.creating++
+}
+
+// createReserved news an object in a slot counted by reserve, and gives the
+// slot up if that fails. Must be called without the lock held.
+func (p *GenericPool) createReserved(ctx context.Context) (PoolObject, error) {
+	poolObj, err := p.createWith(ctx, p.settings())
+	p.Lock()
+	p.creating--
+	if err != nil {
+		p.freeSlot()
+	} else if p.dedupe {
+		// acquires waiting for this creation may create their own now
+		p.broadcast()
+	}
+	p.checkMin()
+	p.Unlock()
+	return poolObj, err
+}
+
+// fillReserved creates n objects in slots counted by reserve and puts them
+// idle. It stops at the first error, giving up the slots left. Must be called
+// without the lock held, as factories may call the pool.
+func (p *GenericPool) fillReserved(n int) error {
+	for i := 0; i < n; i++ {
+		poolObj, err := p.createReserved(context.Background())
+		p.Lock()
+		if err != nil {
+			for left := n - i - 1; left > 0; left-- {
+				p.creating--
+				p.freeSlot()
+			}
+			p.Unlock()
+			return err
+		}
+		if p.closed {
+			p.closeAs(poolObj, EventClosed)
+			p.curNum--
+		} else if !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+		p.Unlock()
+	}
+	return nil
+}
+
+// wait blocks until an object is released, waiters are signalled, or ctx is
+// done. The caller must have counted itself in waiters.
+func (p *GenericPool) wait(ctx context.Context, idle chan PoolObject, signal chan struct{}) (poolObj PoolObject, ok bool, err error) {
+	select {
+	case poolObj, ok = <-idle:
+	case <-signal:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.Lock()
+	p.waiters--
+	if ok && p.paused {
+		// released while paused, keep it for after Resume
+		ok = false
+		if p.closed || !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+	}
+	desaturated := p.desaturate()
+	p.Unlock()
+	if desaturated {
+		p.notify("OnDesaturated", p.onDesaturated)
+	}
+	return poolObj, ok, err
+}
+
+// saturate reports whether OnSaturated should fire for an acquire about to
+// wait on a full pool. It stays quiet shortly after OnDesaturated, so a pool
+// hovering at Max doesn't flood the callbacks. Must be called with the lock
+// held.
+func (p *GenericPool) saturate() bool {
+	if p.saturated || p.since(p.desaturatedAt) < saturationQuiet {
+		return false
+	}
+	p.saturated = true
+	return true
+}
+
+// desaturate reports whether OnDesaturated should fire, which is the case
+// once the last waiter of a saturated pool is gone. Must be called with the
+// lock held.
+func (p *GenericPool) desaturate() bool {
+	if !p.saturated || p.waiters > 0 {
+		return false
+	}
+	p.saturated = false
+	p.desaturatedAt = p.now()
+	return true
+}
+
+// notify calls an optional event callback
+func (p *GenericPool) notify(hook string, fn func()) {
+	if fn == nil {
+		return
+	}
+	p.protect(hook, func() error {
+		fn()
+		return nil
+	})
+}
+
+// freeSlot gives up a slot of maxCap, and wakes up waiters so they may create
+// a new object. Must be called with the lock held.
+func (p *GenericPool) freeSlot() {
+	p.curNum--
+	p.broadcast()
+	p.checkMin()
+}
+
+// checkMin fires OnMinReached once the pool is back at minCap objects after
+// having dropped below. Filling the pool initially doesn't count. Must be
+// called with the lock held.
+func (p *GenericPool) checkMin() {
+	if p.onMinReached == nil || p.closed {
+		return
+	}
+	switch {
+	case p.curNum-p.creating < p.minCap:
+		select {
+		case <-p.ready:
+			p.belowMin = true
+		default:
+		}
+	case p.belowMin:
+		p.belowMin = false
+		go p.notify("OnMinReached", p.onMinReached)
+	}
+}
+
+// broadcast wakes up all waiters. Must be called with the lock held.
+func (p *GenericPool) broadcast() {
+	close(p.signal)
+	p.signal = make(chan struct{})
+	p.notifyCapacity()
+}
+
+// new an object by factory function, and warm it up by the post create
+// function. Objects failing to warm up are closed, and replaced by new ones
+// up to postCreateAttempts times.
+func (p *GenericPool) createObject() (poolObj PoolObject, err error) {
+	return p.createWith(context.Background(), p.settings())
+}
+
+// createWith is createObject with the given settings, passing ctx to
+// FactoryFuncCtx.
+func (p *GenericPool) createWith(ctx context.Context, cfg *settings) (poolObj PoolObject, err error) {
+	for attempt := 1; ; attempt++ {
+		if poolObj, err = p.newObject(ctx, cfg); err != nil {
+			return poolObj, err
+		}
+		if cfg.postCreateFunc != nil {
+			err = p.protect("PostCreateFunc", func() error {
+				return cfg.postCreateFunc(poolObj.Object)
+			})
+		}
+		if err == nil {
+			p.emit(EventCreated, poolObj)
+			return poolObj, nil
+		}
+		p.closeWith(cfg, poolObj.Object)
+		if attempt >= postCreateAttempts {
+			return PoolObject{}, err
+		}
+	}
+}
+
+// new an object by factory function
+func (p *GenericPool) newObject(ctx context.Context, cfg *settings) (PoolObject, error) {
+	var obj interface{}
+	var tag string
+	if p.serialFactory {
+		p.factoryMu.Lock()
+		defer p.factoryMu.Unlock()
+	}
+	if !p.dependencyReady() {
+		return PoolObject{}, ErrDependencyUnavailable
+	}
+	if p.limiter != nil {
+		if err := p.limiter.wait(ctx); err != nil {
+			return PoolObject{}, err
+		}
+		defer p.limiter.done()
+	}
+	if p.createTurn != nil {
+		if err := p.spaceCreate(ctx); err != nil {
+			return PoolObject{}, err
+		}
+	}
+	start := p.now()
+	defer func() {
+		atomic.AddInt64(&p.createNanos, int64(p.since(start)))
+		atomic.AddInt64(&p.createCount, 1)
+	}()
+	err := p.protect("FactoryFunc", func() (err error) {
+		if cfg.taggedFactoryFunc != nil {
+			obj, tag, err = cfg.taggedFactoryFunc()
+			return err
+		}
+		if cfg.statefulFactoryFunc != nil {
+			obj, err = cfg.statefulFactoryFunc(p.Stats())
+			return err
+		}
+		if cfg.contextFactoryFunc != nil {
+			obj, err = cfg.contextFactoryFunc(ctx)
+			return err
+		}
+		obj, err = cfg.factoryFunc()
+		return err
+	})
+	p.recordFactory(err)
+	if err != nil && obj != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		// cancelled half way, the factory left a partial object to clean up
+		p.closeWith(cfg, obj)
+		obj = nil
+	}
+	fallback := err != nil && cfg.fallbackFactoryFunc != nil
+	if fallback {
+		tag = ""
+		err = p.protect("FallbackFactoryFunc", func() (err error) {
+			obj, err = cfg.fallbackFactoryFunc()
+			return err
+		})
+	}
+	if err != nil {
+		return PoolObject{}, err
+	}
+	if p.objType != nil && reflect.TypeOf(obj) != p.objType {
+		p.closeWith(cfg, obj)
+		return PoolObject{}, ErrTypeMismatch
+	}
+	poolObj := p.wrap(obj)
+	poolObj.Tag = tag
+	poolObj.Fallback = fallback
+	return poolObj, nil
+}
+
+// wrap a new object into a PoolObject of this pool
+func (p *GenericPool) wrap(obj interface{}) PoolObject {
+	now := p.now().UnixNano()
+	return PoolObject{
+		CreateTime: now,
+		LastUsed:   now,
+		Object:     obj,
+		Meta:       make(map[string]string),
+		pool:       p,
+		id:         p.ids.next(),
+		gen:        atomic.LoadUint64(&p.generation),
+	}
+}
+
+// close object by close function, or hand it to the recycle function
+func (p *GenericPool) closeObject(obj interface{}) error {
+	return p.closeWith(p.settings(), obj)
+}
+
+// closeWith is closeObject with the given settings.
+func (p *GenericPool) closeWith(cfg *settings, obj interface{}) error {
+	if cfg.recycleFunc != nil {
+		return p.protect("RecycleFunc", func() error {
+			cfg.recycleFunc(obj)
+			return nil
+		})
+	}
+	return p.protect("CloseFunc", func() error {
+		return cfg.closeFunc(obj)
+	})
+}
+
+// protect runs a user callback, and turns a panic into ErrCallbackPanic after
+// passing it to the panic handler.
+func (p *GenericPool) protect(hook string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.panicHandler(r, hook)
+			err = ErrCallbackPanic
+		}
+	}()
+	return fn()
+}
+
+// dependencyReady reports whether ReadyFunc allows creating objects. A
+// panicking ReadyFunc counts as not ready.
+func (p *GenericPool) dependencyReady() bool {
+	if p.readyFunc == nil {
+		return true
+	}
+	ready := false
+	p.protect("ReadyFunc", func() error {
+		ready = p.readyFunc()
+		return nil
+	})
+	return ready
+}
+
+func (p *GenericPool) isStale(poolObj PoolObject) bool {
+	return poolObj.gen != atomic.LoadUint64(&p.generation)
+}
+
+// discard closes an object which won't be handed out anymore, regardless of
+// the close error. Must be called with the lock held.
+func (p *GenericPool) discard(poolObj PoolObject) {
+	p.discardAs(poolObj, EventClosed)
+}
+
+// discardAs is discard, reporting the close as an event of kind.
+func (p *GenericPool) discardAs(poolObj PoolObject, kind EventType) {
+	p.closeAs(poolObj, kind)
+	p.checkin(poolObj.id)
+	p.freeSlot()
+}
+
+// createAhead creates objects in the background when idle objects run low, so
+// that following acquires don't have to wait for the factory. The idle target
+// is ceil(createAheadFactor * objects in use), bounded by maxCap.
+func (p *GenericPool) createAhead() {
+	if p.createAheadFactor <= 0 {
+		return
+	}
+	p.Lock()
+	if p.creatingAhead || !p.needCreateAhead() {
+		p.Unlock()
+		return
+	}
+	p.creatingAhead = true
+	p.Unlock()
+
+	go func() {
+		p.Lock()
+		defer p.Unlock()
+		for p.needCreateAhead() {
+			// reserve the slot so concurrent acquires respect maxCap
+			p.reserve()
+			p.Unlock()
+			poolObj, err := p.createWith(p.bgCtx, p.settings())
+			p.Lock()
+			p.creating--
+			if err != nil {
+				p.freeSlot()
+				break
+			}
+			if p.closed {
+				p.closeAs(poolObj, EventClosed)
+				p.freeSlot()
+				break
+			}
+			if !p.putIdle(poolObj) {
+				p.discard(poolObj)
+			}
+		}
+		p.creatingAhead = false
+	}()
+}
+
+// needCreateAhead must be called with the lock held.
+func (p *GenericPool) needCreateAhead() bool {
+	if p.closed || p.curNum >= p.maxCap {
+		return false
+	}
+	idle := p.idleLen()
+	inUse := p.curNum - idle
+	return idle < int(math.Ceil(p.createAheadFactor*float64(inUse)))
+}
+
+// release object into pool
+func (p *GenericPool) Release(poolObj PoolObject) error {
+	_, err := p.ReleaseOrClose(poolObj)
+	return err
+}
+
+// ReleaseOrClose releases the object into the pool if there is room for it,
+// and closes it otherwise. It reports whether the object was pooled. There is
+// no room once the pool holds more than Max objects, as after shrinking it by
+// Resize. Expired objects and objects evicted while in use are closed too.
+func (p *GenericPool) ReleaseOrClose(poolObj PoolObject) (pooled bool, err error) {
+	span := p.startSpan("pool.Release")
+	defer func() {
+		span.SetAttribute("pool.pooled", pooled)
+		endSpan(span, poolObj, err)
+	}()
+	poolObj.LastError = nil
+	if pooled, err = p.release(poolObj); err != nil {
+		return false, p.misuse(err)
+	}
+	return pooled, nil
+}
+
+// ReleaseWithError releases an object which failed with err. It is closed,
+// unless KeepOnError reports that err leaves it usable. A kept object is
+// pooled with err as its LastError. A nil err is a plain Release.
+func (p *GenericPool) ReleaseWithError(poolObj PoolObject, err error) error {
+	if err == nil {
+		return p.Release(poolObj)
+	}
+	keep := false
+	if p.keepOnError != nil {
+		p.protect("KeepOnError", func() error {
+			keep = p.keepOnError(err)
+			return nil
+		})
+	}
+	if !keep {
+		return p.Close(poolObj)
+	}
+	poolObj.LastError = err
+	if _, err := p.release(poolObj); err != nil {
+		return p.misuse(err)
+	}
+	return nil
+}
+
+// waitSignal waits until signal is closed, or for d at most.
+func waitSignal(signal chan struct{}, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-signal:
+	case <-timer.C:
+	}
+}
+
+// release is ReleaseOrClose without the strict mode panics, for internal
+// callers. It only fails on misuse.
+func (p *GenericPool) release(poolObj PoolObject) (pooled bool, err error) {
+	if p.isOverflow(poolObj) {
+		return poolObj.pool.release(poolObj)
+	}
+	if p.isBorrowed(poolObj) {
+		adopted, ok := p.adopt(poolObj)
+		if !ok {
+			// no room here, give it back
+			return poolObj.pool.release(poolObj)
+		}
+		poolObj = adopted
+	}
+	if p.wrongPool(poolObj) {
+		return false, ErrWrongPool
+	}
+	p.Lock()
+	defer p.Unlock()
+	delay := releaseRetryDelay
+	for retry := 0; retry < p.relRetries && p.curNum > p.maxCap && !p.closed; retry++ {
+		if _, ok := p.inUse[poolObj.id]; !ok {
+			break
+		}
+		// surplus, unless a slot frees up meanwhile
+		signal := p.signal
+		p.Unlock()
+		p.releaseWait(signal, delay)
+		p.Lock()
+		if delay *= 2; delay > releaseRetryMax {
+			delay = releaseRetryMax
+		}
+	}
+	if p.wasReclaimed(poolObj) {
+		return false, nil
+	}
+	if p.closed {
+		if _, ok := p.inUse[poolObj.id]; ok && p.killed && poolObj.pool == p {
+			p.closeAs(poolObj, EventClosed)
+			p.checkin(poolObj.id)
+			p.curNum--
+			return false, nil
+		}
+		return false, ErrPoolClosed
+	}
+	c, ok := p.inUse[poolObj.id]
+	if !ok || poolObj.pool != p {
+		// released twice, or not acquired at all
+		return false, ErrNotInUse
+	}
+	if c.doomed {
+		// evicted while in use
+		p.discardAs(poolObj, EventEvicted)
+		return false, nil
+	}
+	if p.draining {
+		// about to be shut down
+		p.discard(poolObj)
+		return false, nil
+	}
+	if p.curNum > p.maxCap {
+		// surplus after shrinking
+		p.discard(poolObj)
+		return false, nil
+	}
+	if p.settings().refresh {
+		poolObj.CreateTime = p.now().UnixNano()
+	}
+	if p.isLiftTimeOut(poolObj) || p.isStale(poolObj) {
+		// created before the last recycle, or maxLifeTime
+		p.discardAs(poolObj, EventExpired)
+		return false, nil
+	}
+	poolObj.bytes = c.bytes
+	poolObj.uses, poolObj.held = c.poolObj.uses+1, c.poolObj.held+p.since(c.since)
+	poolObj.LastUsed = p.now().UnixNano()
+	if p.maxBytes > 0 && c.bytes >= p.maxBytes {
+		// worn out by traffic
+		p.discardAs(poolObj, EventExpired)
+		return false, nil
+	}
+	if !c.reset && p.deferReset(poolObj) {
+		p.checkin(poolObj.id)
+		p.emit(EventReleased, poolObj)
+		return true, nil
+	}
+	if !c.reset && p.reset(poolObj) != nil {
+		p.discard(poolObj)
+		return false, nil
+	}
+	p.checkin(poolObj.id)
+	poolObj.idleSince = 0
+	if !p.putIdle(poolObj) {
+		// no room left in the idle channel
+		p.discard(poolObj)
+		return false, nil
+	}
+	p.emit(EventReleased, poolObj)
+	return true, nil
+}
+
+// ReleaseContext is Release, with the reset function bounded by ctx. If ctx
+// is done before the object has been reset, it is closed instead of pooled,
+// once the reset function returns, and ctx.Err() is returned.
+func (p *GenericPool) ReleaseContext(ctx context.Context, poolObj PoolObject) error {
+	p.Lock()
+	c, ok := p.inUse[poolObj.id]
+	p.Unlock()
+	if p.settings().resetFunc == nil || !ok || poolObj.pool != p {
+		// nothing slow to do, or misuse for Release to report
+		return p.Release(poolObj)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- p.reset(poolObj)
+	}()
+	select {
+	case err := <-done:
+		p.Lock()
+		c.reset = true
+		c.doomed = c.doomed || err != nil
+		p.Unlock()
+		return p.Release(poolObj)
+	case <-ctx.Done():
+	}
+	p.Lock()
+	// the object may have been force reclaimed meanwhile, and closed already
+	closeLater := p.inUse[poolObj.id] == c
+	if closeLater {
+		p.checkin(poolObj.id)
+		p.freeSlot()
+	}
+	p.Unlock()
+	if closeLater {
+		go func() {
+			<-done
+			p.closeAs(poolObj, EventClosed)
+		}()
+	}
+	return ctx.Err()
+}
+
+// reset clears the object by the reset function, if any
+func (p *GenericPool) reset(poolObj PoolObject) error {
+	resetFunc := p.settings().resetFunc
+	if resetFunc == nil {
+		return nil
+	}
+	return p.protect("ResetFunc", func() error {
+		resetFunc(poolObj.Object)
+		return nil
+	})
+}
+
+// deferReset queues an object for the reset worker, and reports false if it
+// must be reset right away: without DeferReset, with nothing to reset, or
+// when the queue is full after growing the pool. Must be called with the lock
+// held.
+func (p *GenericPool) deferReset(poolObj PoolObject) bool {
+	if p.resetQueue == nil || p.settings().resetFunc == nil {
+		return false
+	}
+	select {
+	case p.resetQueue <- poolObj:
+		p.resetting++
+		return true
+	default:
+		return false
+	}
+}
+
+// resetWorker resets the objects queued by deferReset and pools them.
+func (p *GenericPool) resetWorker() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case poolObj := <-p.resetQueue:
+			err := p.reset(poolObj)
+			p.Lock()
+			p.resetting--
+			switch {
+			case p.closed:
+				// shut down meanwhile, which only closed the queued objects
+				p.closeAs(poolObj, EventClosed)
+				p.curNum--
+			case err != nil:
+				p.discard(poolObj)
+			default:
+				poolObj.idleSince = 0
+				if !p.putIdle(poolObj) {
+					p.discard(poolObj)
+				}
+			}
+			p.Unlock()
+		}
+	}
+}
+
+// resetObject is the ResetFunc of AutoReset
+func resetObject(obj interface{}) {
+	if r, ok := obj.(interface{ Reset() }); ok {
+		r.Reset()
+	}
+}
+
+// Close closes an object in use, instead of releasing it. Closing an object
+// idle in the pool fails with ErrObjectIdle, idle objects are closed by
+// EvictWhere.
+func (p *GenericPool) Close(poolObj PoolObject) (err error) {
+	span := p.startSpan("pool.Close")
+	defer func() {
+		endSpan(span, poolObj, err)
+	}()
+	if p.isOverflow(poolObj) || p.isBorrowed(poolObj) {
+		return poolObj.pool.Close(poolObj)
+	}
+	if p.wrongPool(poolObj) {
+		return p.misuse(ErrWrongPool)
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.wasReclaimed(poolObj) {
+		return nil
+	}
+	if _, ok := p.inUse[poolObj.id]; !ok || poolObj.pool != p {
+		if poolObj.pool == p && p.isIdle(poolObj.id) {
+			// released already
+			return p.misuse(ErrObjectIdle)
+		}
+		// closed twice, or not acquired at all
+		return p.misuse(ErrNotInUse)
+	}
+	if err := p.closeAs(poolObj, EventClosed); err != nil {
+		return err
+	}
+	p.checkin(poolObj.id)
+	p.freeSlot()
+	return nil
+}
+
+// misuse panics with err in strict mode, and returns it otherwise.
+func (p *GenericPool) misuse(err error) error {
+	if p.strictMode {
+		panic(err)
+	}
+	return err
+}
+
+// wrongPool reports whether the object was acquired from another pool. Every
+// object carries the pool which created it as its receipt.
+func (p *GenericPool) wrongPool(poolObj PoolObject) bool {
+	return poolObj.pool != nil && poolObj.pool != p
+}
+
+// wasReclaimed reports whether the object has already been closed by the
+// checkout monitor, and forgets about it. Must be called with the lock held.
+func (p *GenericPool) wasReclaimed(poolObj PoolObject) bool {
+	if _, ok := p.reclaimed[poolObj.id]; ok {
+		delete(p.reclaimed, poolObj.id)
+		return true
+	}
+	return false
+}
+
+// checkoutMonitor periodically looks for objects held longer than
+// maxCheckoutTime, until the pool is shut down.
+func (p *GenericPool) checkoutMonitor() {
+	interval := p.maxCheckoutTime / 2
+	if interval < minMonitorInterval {
+		interval = minMonitorInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.checkOverdue()
+		}
+	}
+}
+
+func (p *GenericPool) checkOverdue() {
+	p.Lock()
+	defer p.Unlock()
+	// forget objects whose holders never came back, releasing them later
+	// fails with ErrNotInUse
+	for id, at := range p.reclaimed {
+		if p.since(at) >= reclaimedRetention {
+			delete(p.reclaimed, id)
+		}
+	}
+	for id, c := range p.inUse {
+		held := p.since(c.since)
+		if held < p.maxCheckoutTime {
+			continue
+		}
+		if !c.reported {
+			c.reported = true
+			p.overdueCount++
+			p.logger.Printf("[POOL][WARN] object %d checked out for %v, exceeding %v.", id, held, p.maxCheckoutTime)
+		}
+		if p.forceReclaim {
+			p.discard(c.poolObj)
+			p.reclaimed[id] = p.now()
+		}
+	}
+}
+
+// inactivityMonitor shuts the pool down after autoShutdown without acquires.
+func (p *GenericPool) inactivityMonitor() {
+	interval := p.autoShutdown / 2
+	if interval < minMonitorInterval {
+		interval = minMonitorInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.Lock()
+			if !p.closed && len(p.inUse) == 0 && p.since(p.lastAcquire) >= p.autoShutdown {
+				p.logger.Printf("[POOL][INFO] no acquires for %v, shutting down.", p.autoShutdown)
+				p.shutdown()
+			}
+			p.Unlock()
+		}
+	}
+}
+
+// number of objects which have been held longer than MaxCheckoutTime, or the
+// maxHold of AcquireWatched
+func (p *GenericPool) OverdueCount() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.overdueCount
+}
+
+// shutdown current pool, and remove all object from that pool
+func (p *GenericPool) Shutdown() error {
+	return p.ShutdownWithResult().Err
+}
+
+// ShutdownResult tells how cleanly a pool was shut down.
+type ShutdownResult struct {
+	Closed      int   // idle objects closed
+	Failed      int   // idle objects whose close failed
+	Outstanding int   // objects still in use
+	Err         error // close errors joined, or ErrPoolClosed if already shut down
+}
+
+// ShutdownWithResult is Shutdown, reporting what happened to the objects.
+func (p *GenericPool) ShutdownWithResult() ShutdownResult {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return ShutdownResult{Err: ErrPoolClosed}
+	}
+	return p.shutdown()
+}
+
+// ShutdownContext shuts down the pool gracefully. It closes the idle objects
+// and waits for the objects in use, closing them as they are released, before
+// shutting down. Meanwhile acquires fail with ErrPoolDraining. If ctx is done
+// first, the pool is shut down anyway, and ctx.Err() is returned.
+func (p *GenericPool) ShutdownContext(ctx context.Context) error {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed || p.draining {
+		return ErrPoolClosed
+	}
+	p.draining = true
+	idle := p.drainIdle()
+	outstanding := len(p.inUse)
+	progress := p.drainProgress(len(idle) + outstanding)
+	for _, poolObj := range idle {
+		p.discard(poolObj)
+		progress.done(1)
+	}
+	// wake waiters to fail
+	p.broadcast()
+	var err error
+	for len(p.inUse) > 0 && err == nil {
+		signal := p.signal
+		p.Unlock()
+		select {
+		case <-signal:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		p.Lock()
+		// objects released meanwhile were closed
+		progress.done(outstanding - len(p.inUse))
+		outstanding = len(p.inUse)
+	}
+	if p.closed {
+		// shut down meanwhile
+		return ErrPoolClosed
+	}
+	if result := p.shutdown(); err == nil {
+		err = result.Err
+	}
+	return err
+}
+
+// Kill shuts down the pool like Shutdown, and also closes objects in use when
+// they are released, instead of failing the release with ErrPoolClosed. It
+// doesn't wait for them.
+func (p *GenericPool) Kill() error {
+	p.Lock()
+	defer p.Unlock()
+	if p.killed {
+		return ErrPoolClosed
+	}
+	p.killed = true
+	if p.closed {
+		// already shut down, only the objects in use are left
+		return nil
+	}
+	return p.shutdown().Err
+}
+
+// shutdown closes the pool and all idle objects. Must be called with the lock
+// held.
+func (p *GenericPool) shutdown() ShutdownResult {
+	p.closed = true
+	close(p.done)
+	p.bgCancel()
+	idle := p.drainIdle()
+	close(p.pool)
+	p.broadcast()
+	if p.overflow != nil && p.killed {
+		p.overflow.Kill()
+	} else if p.overflow != nil {
+		p.overflow.Shutdown()
+	}
+	if p.registry != nil {
+		p.registry.Deregister(p)
+	}
+	if p.resetQueue != nil {
+		// objects still awaiting their reset are as good as idle
+		for len(p.resetQueue) > 0 {
+			idle = append(idle, <-p.resetQueue)
+			p.resetting--
+		}
+	}
+	result := ShutdownResult{Outstanding: len(p.inUse)}
+	var errs []error
+	progress := p.drainProgress(len(idle))
+	for _, poolObj := range idle {
+		if err := p.closeAs(poolObj, EventClosed); err != nil {
+			errs = append(errs, err)
+			result.Failed++
+		} else {
+			result.Closed++
+		}
+		p.curNum--
+		progress.done(1)
+	}
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+// Recycle closes all idle objects and refills the pool with fresh ones up to
+// minCap. Objects in use at the time are closed when they are released.
+func (p *GenericPool) Recycle() error {
+	p.Lock()
+	if p.closed {
+		p.Unlock()
+		return ErrPoolClosed
+	}
+	atomic.AddUint64(&p.generation, 1)
+	for _, poolObj := range p.drainIdle() {
+		p.discardAs(poolObj, EventExpired)
+	}
+	n := p.minCap - p.idleLen()
+	if room := p.maxCap - p.curNum; room < n {
+		n = room
+	}
+	for i := 0; i < n; i++ {
+		p.reserve()
+	}
+	p.checkMin()
+	p.Unlock()
+	return p.fillReserved(n)
+}
+
+// AcquireNewerThan acquires an object created after t. Older idle objects are
+// skipped, and a fresh object is created if none qualifies. When the pool is
+// full, an older object is closed to make room for it.
+func (p *GenericPool) AcquireNewerThan(t time.Time) (PoolObject, error) {
+	newer := func(poolObj PoolObject) bool {
+		return poolObj.CreateTime > t.UnixNano()
+	}
+	for {
+		p.Lock()
+		if p.closed {
+			p.Unlock()
+			return PoolObject{}, ErrPoolClosed
+		}
+		if p.draining {
+			p.Unlock()
+			return PoolObject{}, ErrPoolDraining
+		}
+		if p.paused && p.failWhenPaused {
+			p.Unlock()
+			return PoolObject{}, ErrPoolPaused
+		}
+		if !p.paused {
+			var found *PoolObject
+			for _, poolObj := range p.drainIdle() {
+				if found == nil && newer(poolObj) && !p.isLiftTimeOut(poolObj) && !p.isStale(poolObj) {
+					found = &poolObj
+				} else if !p.putIdle(poolObj) {
+					p.discard(poolObj)
+				}
+			}
+			if found != nil {
+				p.Unlock()
+				p.checkout(*found)
+				return *found, nil
+			}
+			if p.curNum >= p.maxCap {
+				if old, ok := p.takeIdle(); ok {
+					p.discard(old)
+				}
+			}
+			if p.curNum < p.maxCap {
+				p.reserve()
+				p.Unlock()
+				poolObj, err := p.createReserved(context.Background())
+				if err != nil {
+					return poolObj, err
+				}
+				p.checkout(poolObj)
+				return poolObj, nil
+			}
+		}
+		p.Unlock()
+
+		// every object is in use, or the pool is paused, wait for one and
+		// replace it if too old
+		poolObj, err := p.Acquire()
+		if err != nil || newer(poolObj) {
+			return poolObj, err
+		}
+		p.Close(poolObj)
+	}
+}
+
+// Stream acquires objects one after another and yields them on the returned
+// channel, until ctx is cancelled or the pool is shut down. Failed acquires
+// are retried with backoff. The caller must release every object it receives.
+// The channel is closed when the stream ends.
+func (p *GenericPool) Stream(ctx context.Context) <-chan PoolObject {
+	ch := make(chan PoolObject)
+	go func() {
+		defer close(ch)
+		backoff := streamRetryMin
+		for {
+			poolObj, err := p.acquireFunc()(ctx)
+			if err == ErrPoolClosed || ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return
+				}
+				if backoff *= 2; backoff > streamRetryMax {
+					backoff = streamRetryMax
+				}
+				continue
+			}
+			backoff = streamRetryMin
+			select {
+			case ch <- poolObj:
+			case <-ctx.Done():
+				// nobody took it, give it back. This fails harmlessly
+				// if the pool has been shut down meanwhile.
+				p.release(poolObj)
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Resize changes the max capacity of the pool. When shrinking, surplus idle
+// objects are closed right away, and surplus objects in use are closed when
+// they are released.
+func (p *GenericPool) Resize(max int) error {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if max <= 0 || max < p.minCap {
+		return ErrInvalidConfig
+	}
+	p.resize(max)
+	return nil
+}
+
+// ResizeContext is Resize, waiting until the surplus objects in use have been
+// released and closed, and the pool holds no more than max objects. If ctx is
+// done first, ctx.Err() is returned, and the remaining surplus is still closed
+// on release.
+func (p *GenericPool) ResizeContext(ctx context.Context, max int) error {
+	if err := p.Resize(max); err != nil {
+		return err
+	}
+	p.Lock()
+	defer p.Unlock()
+	for p.curNum > p.maxCap {
+		if p.closed {
+			return ErrPoolClosed
+		}
+		signal := p.signal
+		p.Unlock()
+		select {
+		case <-signal:
+		case <-ctx.Done():
+			p.Lock()
+			return ctx.Err()
+		}
+		p.Lock()
+	}
+	return nil
+}
+
+// resize sets maxCap to a validated max. Must be called with the lock held.
+func (p *GenericPool) resize(max int) {
+	p.maxCap = max
+	atomic.StoreInt64(&p.maxCount, int64(max))
+	idle := p.drainIdle()
+	p.pool = make(chan PoolObject, max)
+	for _, poolObj := range idle {
+		if p.curNum > max || !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+	}
+	// waiters go back to waiting on the new channel, or create objects
+	p.broadcast()
+}
+
+// CancelWaiters makes all acquires waiting for an object return err, or
+// ErrAcquireCancelled if err is nil, to shed load in an emergency. The pool
+// stays open, and later acquires are not affected.
+func (p *GenericPool) CancelWaiters(err error) {
+	if err == nil {
+		err = ErrAcquireCancelled
+	}
+	p.Lock()
+	p.cancelErr = err
+	atomic.AddUint64(&p.cancels, 1)
+	p.broadcast()
+	p.Unlock()
+}
+
+// Pause stops handing out objects, while objects in use can still be released.
+// Acquires block until Resume, or fail with ErrPoolPaused if FailWhenPaused.
+func (p *GenericPool) Pause() {
+	p.Lock()
+	p.paused = true
+	// waiters go back to waiting, without taking released objects
+	p.broadcast()
+	p.Unlock()
+}
+
+// Resume hands out objects again, including to acquires blocked by Pause.
+func (p *GenericPool) Resume() {
+	p.Lock()
+	p.paused = false
+	p.broadcast()
+	p.Unlock()
+}
+
+// object numbers in current pool
+func (p *GenericPool) Len() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.idleLen()
+}
+
+func (p *GenericPool) IsClosed() bool {
+	p.Lock()
+	defer p.Unlock()
 	return p.closed
 }
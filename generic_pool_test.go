@@ -1,19 +1,49 @@
 package pool
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"math/rand"
 	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/valyala/fasthttp"
 )
 
+var factorySeq int32
+
+// factory creates distinct ints, but tests asserting on which object they get
+// should use PoolObject.ID, which doesn't depend on the factory.
 func factory() (interface{}, error) {
-	rand.Seed(time.Now().UnixNano())
-	x := rand.Intn(100)
-	return x, nil
+	return int(atomic.AddInt32(&factorySeq, 1)), nil
+}
+
+// setObjectCreateTime ages an object, in the hands of the test or idle in its
+// pool, without sleeping.
+func setObjectCreateTime(obj *PoolObject, t int64) {
+	obj.CreateTime = t
+	p := obj.pool
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	for _, poolObj := range p.drainIdle() {
+		if poolObj.id == obj.id {
+			poolObj.CreateTime = t
+		}
+		p.putIdle(poolObj)
+	}
+}
+
+// aged returns a create time that makes objects older than lifeTime
+func aged(lifeTime time.Duration) int64 {
+	return time.Now().Add(-lifeTime).UnixNano()
 }
 
 func closer(o interface{}) error {
@@ -54,7 +84,7 @@ func responseCloser(i interface{}) error {
 var config = &PoolConfig{
 	Min:         3,
 	Max:         5,
-	LiftTime:    5,
+	LiftTime:    5 * time.Second,
 	FactoryFunc: clientFactory,
 	CloseFunc:   clientCloser,
 }
@@ -62,7 +92,7 @@ var config = &PoolConfig{
 var requestConfig = &PoolConfig{
 	Min:         3,
 	Max:         5,
-	LiftTime:    5,
+	LiftTime:    5 * time.Second,
 	FactoryFunc: requestFactory,
 	CloseFunc:   requestCloser,
 }
@@ -70,7 +100,7 @@ var requestConfig = &PoolConfig{
 var responseConfig = &PoolConfig{
 	Min:         3,
 	Max:         5,
-	LiftTime:    5,
+	LiftTime:    5 * time.Second,
 	FactoryFunc: responseFactory,
 	CloseFunc:   responseCloser,
 }
@@ -83,47 +113,62 @@ func TestNewGenericPool(t *testing.T) {
 	t.Log("[SUCC]", pool.Len())
 }
 
+var intConfig = &PoolConfig{
+	Min:         3,
+	Max:         5,
+	LiftTime:    5 * time.Second,
+	FactoryFunc: factory,
+	CloseFunc:   closer,
+}
+
 func TestGenericPool_Acquire(t *testing.T) {
-	pool, err := NewGenericPool(config)
+	pool, err := NewGenericPool(intConfig)
 	if err != nil {
-		t.Log("[ERR]", err)
+		t.Fatal("[ERR]", err)
 	}
 	t.Log("[SUCC]", pool.Len())
-	v1, err := pool.Acquire()
-	if err != nil {
-		t.Log("[ERR]", err)
-	}
-	t.Logf("[SUCC] %T %+v", v1, v1.Object.(int))
 
-	v2, _ := pool.Acquire()
-	t.Logf("[SUCC] %T %+v", v2, v2.Object.(int))
-	v3, _ := pool.Acquire()
-	t.Logf("[SUCC] %T %+v", v3, v3.Object.(int))
-	v4, err := pool.Acquire()
-	if err != nil {
-		t.Log("[ERR]", err)
+	// the Min objects created up front come first, then new ones
+	for want := uint64(1); want <= 4; want++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if v.ID() != want {
+			t.Fatalf("[ERR] expected object %d, got %d", want, v.ID())
+		}
+		t.Logf("[SUCC] %T %+v", v, v.Object.(int))
 	}
 	t.Log("[SUCC]", pool.Len())
-	t.Logf("[SUCC] %T %+v", v4, v4.Object.(int))
 }
 
 func TestGenericPool_Release(t *testing.T) {
-	pool, err := NewGenericPool(config)
+	pool, err := NewGenericPool(intConfig)
 	if err != nil {
-		t.Log("[ERR]", err)
+		t.Fatal("[ERR]", err)
 	}
 	t.Log("[SUCC]", pool.Len())
 
 	v1, err := pool.Acquire()
 	if err != nil {
-		t.Log("[ERR]", err)
+		t.Fatal("[ERR]", err)
 	}
 	t.Log("[SUCC]", pool.Len())
-	t.Logf("[SUCC] %T %+v", v1, v1.Object.(int))
 	if err := pool.Release(v1); err != nil {
-		t.Log("[ERR]", err)
+		t.Fatal("[ERR]", err)
 	}
 	t.Log("[SUCC]", pool.Len())
+
+	// a released object queues up behind the ones idle for longer
+	for _, want := range []uint64{2, 3, 1} {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if v.ID() != want {
+			t.Fatalf("[ERR] expected object %d, got %d", want, v.ID())
+		}
+	}
 }
 
 func TestGenericPool_Close(t *testing.T) {
@@ -158,6 +203,48 @@ func TestGenericPool_Shutdown(t *testing.T) {
 	t.Log("[SUCC]", pool.Len())
 }
 
+func TestGenericPool_IdleAfterShutdown(t *testing.T) {
+	pool, err := NewGenericPool(intConfig)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.Release(v); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.Shutdown(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	// the pool channel is closed now, which must not read as endless idle
+	// objects
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.ForEachIdle(func(PoolObject) {
+			t.Error("[ERR] idle object after shutdown")
+		})
+		if state := pool.SnapshotState(); state.Idle != 0 {
+			t.Errorf("[ERR] expected no idle objects in the state, got %d", state.Idle)
+		}
+		if stats := pool.ObjectStats(); len(stats) != 0 {
+			t.Errorf("[ERR] expected no object stats, got %d", len(stats))
+		}
+		pool.Reconfigure(intConfig)
+		if err := pool.Close(v); err == nil {
+			t.Error("[ERR] closed a released object after shutdown")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("[ERR] idle objects hang after shutdown")
+	}
+}
+
 func TestFastHttpHostClient(t *testing.T) {
 	c := fasthttp.HostClient{}
 	statusCode, body, err := c.Get(nil, "http://www.google.com.hk")
@@ -268,3 +355,2960 @@ func TestFastHttpClient4(t *testing.T) {
 	}
 
 }
+
+func TestGenericPool_CreateAhead(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:               1,
+		Max:               5,
+		FactoryFunc:       factory,
+		CloseFunc:         closer,
+		CreateAheadFactor: 1,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	for i := 0; i < 3; i++ {
+		if _, err := pool.Acquire(); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+
+	// 3 objects in use ask for 3 idle ones, but only 2 fit below Max
+	deadline := time.Now().Add(time.Second)
+	for {
+		pool.Lock()
+		curNum, idle := pool.curNum, pool.idleLen()
+		pool.Unlock()
+		if curNum == 5 && idle == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("[ERR] expected pool to create ahead up to 5, got %d objects and %d idle", curNum, idle)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Log("[SUCC]", pool.Len())
+}
+
+func TestGenericPool_ForceReclaim(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:             1,
+		Max:             2,
+		FactoryFunc:     factory,
+		CloseFunc:       closer,
+		MaxCheckoutTime: 30 * time.Millisecond,
+		ForceReclaim:    true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	v1, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		pool.Lock()
+		curNum := pool.curNum
+		pool.Unlock()
+		if curNum == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected overdue object to be reclaimed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := pool.OverdueCount(); n != 1 {
+		t.Fatalf("[ERR] expected 1 overdue object, got %d", n)
+	}
+
+	// the late release is accepted, but the object is not pooled again
+	if err := pool.Release(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if pool.Len() != 0 {
+		t.Fatalf("[ERR] expected reclaimed object not to be pooled, len %d", pool.Len())
+	}
+	pool.Lock()
+	curNum := pool.curNum
+	pool.Unlock()
+	if curNum != 0 {
+		t.Fatalf("[ERR] expected curNum 0, got %d", curNum)
+	}
+	t.Log("[SUCC]", pool.Len())
+}
+
+func TestGenericPool_Recycle(t *testing.T) {
+	var created int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 2,
+		Max: 4,
+		FactoryFunc: func() (interface{}, error) {
+			return int(atomic.AddInt32(&created, 1)), nil
+		},
+		CloseFunc: closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	oldest := int(atomic.LoadInt32(&created))
+
+	if err := pool.Recycle(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if pool.Len() != 2 {
+		t.Fatalf("[ERR] expected idle objects restored to 2, got %d", pool.Len())
+	}
+	for i := 0; i < 2; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if v.Object.(int) <= oldest {
+			t.Fatalf("[ERR] expected a fresh object, got %d", v.Object.(int))
+		}
+		defer pool.Release(v)
+	}
+
+	// the object in use during recycle is closed on release
+	if err := pool.Release(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool.Lock()
+	curNum := pool.curNum
+	pool.Unlock()
+	if curNum != 2 || pool.Len() != 0 {
+		t.Fatalf("[ERR] expected old object to be closed, curNum %d len %d", curNum, pool.Len())
+	}
+	t.Log("[SUCC]", pool.Len())
+}
+
+func TestGenericPool_StrictMode(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		pool, err := NewGenericPool(&PoolConfig{
+			Min:         1,
+			Max:         2,
+			FactoryFunc: factory,
+			CloseFunc:   closer,
+			StrictMode:  strict,
+		})
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		v1, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if err := pool.Release(v1); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+
+		var recovered interface{}
+		func() {
+			defer func() { recovered = recover() }()
+			err = pool.Release(v1)
+		}()
+		if strict {
+			if recovered != ErrNotInUse {
+				t.Fatalf("[ERR] expected double release to panic, got %v", recovered)
+			}
+		} else {
+			if recovered != nil || err != ErrNotInUse {
+				t.Fatalf("[ERR] expected double release to return error, got %v %v", recovered, err)
+			}
+		}
+		if pool.Len() != 1 {
+			t.Fatalf("[ERR] expected object to be pooled once, len %d", pool.Len())
+		}
+	}
+}
+
+func TestNewGenericPoolTyped(t *testing.T) {
+	pool, err := NewGenericPoolTyped(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	}, 0)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	// factory returns a string where an int is expected
+	var closed int32
+	pool, err = NewGenericPoolTyped(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: func() (interface{}, error) { return "x", nil },
+		CloseFunc: func(interface{}) error {
+			atomic.AddInt32(&closed, 1)
+			return nil
+		},
+	}, 0)
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("[ERR] expected constructor to fail with ErrTypeMismatch, got %v", err)
+	}
+	if n := atomic.LoadInt32(&closed); n != initAttemptFactor {
+		t.Fatalf("[ERR] expected every mismatched object to be closed, got %d", n)
+	}
+	if _, err := pool.Acquire(); err != ErrTypeMismatch {
+		t.Fatalf("[ERR] expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestGenericPool_Stream(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := pool.Stream(ctx)
+	for i := 0; i < 5; i++ {
+		v := <-stream
+		if err := pool.Release(v); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+
+	cancel()
+	for v := range stream {
+		pool.Release(v)
+	}
+	pool.Lock()
+	inUse := len(pool.inUse)
+	pool.Unlock()
+	if inUse != 0 {
+		t.Fatalf("[ERR] expected no objects in use after cancel, got %d", inUse)
+	}
+	t.Log("[SUCC]", pool.Len())
+}
+
+func TestGenericPool_PanicHandler(t *testing.T) {
+	var calls int32
+	var hooks []string
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 1,
+		Max: 3,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) == 2 {
+				panic("factory exploded")
+			}
+			return 1, nil
+		},
+		CloseFunc: func(interface{}) error {
+			panic("close exploded")
+		},
+		PanicHandler: func(recovered interface{}, hook string) {
+			hooks = append(hooks, hook)
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if _, err := pool.Acquire(); err != ErrCallbackPanic {
+		t.Fatalf("[ERR] expected ErrCallbackPanic, got %v", err)
+	}
+	if err := pool.Close(v1); err != ErrCallbackPanic {
+		t.Fatalf("[ERR] expected ErrCallbackPanic, got %v", err)
+	}
+	if len(hooks) != 2 || hooks[0] != "FactoryFunc" || hooks[1] != "CloseFunc" {
+		t.Fatalf("[ERR] expected handler to fire for both hooks, got %v", hooks)
+	}
+
+	// the pool keeps working after the panics
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", pool.Len())
+}
+
+func TestGenericPool_Pause(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool.Pause()
+	acquired := make(chan error)
+	go func() {
+		_, err := pool.Acquire()
+		acquired <- err
+	}()
+	select {
+	case err := <-acquired:
+		t.Fatalf("[ERR] expected acquire to block while paused, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.Resume()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("[ERR] expected acquire to proceed after resume")
+	}
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+}
+
+func TestGenericPool_PauseFail(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:            1,
+		Max:            2,
+		FactoryFunc:    factory,
+		CloseFunc:      closer,
+		FailWhenPaused: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool.Pause()
+	if _, err := pool.Acquire(); err != ErrPoolPaused {
+		t.Fatalf("[ERR] expected ErrPoolPaused, got %v", err)
+	}
+	pool.Resume()
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+}
+
+func TestGenericPool_LessFunc(t *testing.T) {
+	scores := []int{5, 3, 8}
+	var created int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 3,
+		Max: 3,
+		FactoryFunc: func() (interface{}, error) {
+			return scores[atomic.AddInt32(&created, 1)-1], nil
+		},
+		CloseFunc: closer,
+		LessFunc: func(a, b PoolObject) bool {
+			return a.Object.(int) < b.Object.(int)
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	if v1.Object.(int) != 3 {
+		t.Fatalf("[ERR] expected best score 3, got %d", v1.Object.(int))
+	}
+	pool.Release(v1)
+	v1, _ = pool.Acquire()
+	if v1.Object.(int) != 3 {
+		t.Fatalf("[ERR] expected released best score 3, got %d", v1.Object.(int))
+	}
+	for _, want := range []int{5, 8} {
+		v, _ := pool.Acquire()
+		if v.Object.(int) != want {
+			t.Fatalf("[ERR] expected score %d, got %d", want, v.Object.(int))
+		}
+	}
+}
+
+func TestNewGenericPool_FailingFactory(t *testing.T) {
+	var calls int32
+	_, err := NewGenericPool(&PoolConfig{
+		Min: 5,
+		Max: 5,
+		FactoryFunc: func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, errors.New("backend down")
+		},
+		CloseFunc: closer,
+	})
+	if !errors.Is(err, ErrFactoryFunc) {
+		t.Fatalf("[ERR] expected ErrFactoryFunc, got %v", err)
+	}
+	// giving up after a bounded number of attempts, not retrying forever
+	if n := atomic.LoadInt32(&calls); n != 5*initAttemptFactor {
+		t.Fatalf("[ERR] expected %d factory attempts, got %d", 5*initAttemptFactor, n)
+	}
+	t.Log("[SUCC]", err)
+}
+
+func TestGenericPool_WaiterCount(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := pool.Acquire()
+			if err != nil {
+				t.Error("[ERR]", err)
+				return
+			}
+			pool.Release(v)
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().WaiterCount != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("[ERR] expected 3 waiters, got %d", pool.Stats().WaiterCount)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	pool.Release(v1)
+	wg.Wait()
+	if stats := pool.Stats(); stats.WaiterCount != 0 || stats.Idle != 1 {
+		t.Fatalf("[ERR] expected no waiters and 1 idle object, got %+v", stats)
+	}
+}
+
+func TestGenericPool_ResizeRelease(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         5,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var objs []PoolObject
+	for i := 0; i < 5; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		objs = append(objs, v)
+	}
+	if err := pool.Resize(2); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	for _, v := range objs {
+		if err := pool.Release(v); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.Total != 2 {
+		t.Fatalf("[ERR] expected 2 pooled and 3 closed, got %+v", stats)
+	}
+}
+
+func TestGenericPool_ReleaseRetries(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:            1,
+		Max:            2,
+		FactoryFunc:    factory,
+		CloseFunc:      closer,
+		ReleaseRetries: 8,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	v1, _ := pool.Acquire()
+	v2, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.Resize(1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// v1 is surplus until v2 is closed while its release waits for a retry
+	retrying := make(chan struct{})
+	closed := make(chan struct{})
+	var waits int32
+	pool.releaseWait = func(chan struct{}, time.Duration) {
+		if atomic.AddInt32(&waits, 1) == 1 {
+			close(retrying)
+			<-closed
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Release(v1)
+	}()
+	<-retrying
+	if err := pool.Close(v2); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	close(closed)
+	if err := <-done; err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Idle != 1 || stats.Total != 1 {
+		t.Fatalf("[ERR] expected the retried object to be pooled, got %+v", stats)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_ReleaseRetriesExhausted(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:            1,
+		Max:            2,
+		FactoryFunc:    factory,
+		CloseFunc:      closer,
+		ReleaseRetries: maxReleaseRetries,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	if err := pool.Resize(1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var waits int32
+	pool.releaseWait = func(chan struct{}, time.Duration) {
+		atomic.AddInt32(&waits, 1)
+	}
+	// v2 stays in use, so v1 is closed once its retries run out
+	if err := pool.Release(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := atomic.LoadInt32(&waits); n != maxReleaseRetries {
+		t.Fatalf("[ERR] expected %d retries, got %d", maxReleaseRetries, n)
+	}
+	if stats := pool.Stats(); stats.Idle != 0 || stats.Total != 1 {
+		t.Fatalf("[ERR] expected the surplus object closed, got %+v", stats)
+	}
+	pool.Release(v2)
+}
+
+func TestGenericPool_ResizeContext(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         4,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var objs []PoolObject
+	for i := 0; i < 4; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		objs = append(objs, v)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.ResizeContext(context.Background(), 2)
+	}()
+	for i, v := range objs {
+		select {
+		case err := <-done:
+			t.Fatalf("[ERR] resize returned with %d objects still to release: %v", len(objs)-i, err)
+		case <-time.After(10 * time.Millisecond):
+		}
+		if err := pool.Release(v); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		// the first two releases are surplus, and closed
+		if total, want := pool.Stats().Total, 4-i-1; i < 2 && total != want {
+			t.Fatalf("[ERR] expected %d objects after release %d, got %d", want, i+1, total)
+		}
+		if i == 1 {
+			break
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	for _, v := range objs[2:] {
+		pool.Release(v)
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.Total != 2 {
+		t.Fatalf("[ERR] expected 2 pooled objects, got %+v", stats)
+	}
+
+	// a pending resize gives up with ctx
+	v, _ := pool.Acquire()
+	pool.Acquire()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.ResizeContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("[ERR] expected DeadlineExceeded, got %v", err)
+	}
+	pool.Release(v)
+	if stats := pool.Stats(); stats.Total != 1 {
+		t.Fatalf("[ERR] expected the surplus closed on release, got %+v", stats)
+	}
+}
+
+func TestGenericPool_MaxCheckoutTimeTiny(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:             1,
+		Max:             1,
+		FactoryFunc:     factory,
+		CloseFunc:       closer,
+		MaxCheckoutTime: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.OverdueCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected object to be reported overdue")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGenericPool_CloseTwice(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	other, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	if err := pool.Close(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.Close(v1); err != ErrNotInUse {
+		t.Fatalf("[ERR] expected ErrNotInUse closing twice, got %v", err)
+	}
+	v2, _ := other.Acquire()
+	if err := pool.Close(v2); err != ErrWrongPool {
+		t.Fatalf("[ERR] expected ErrWrongPool closing foreign object, got %v", err)
+	}
+	if total := pool.Stats().Total; total != 0 {
+		t.Fatalf("[ERR] expected 0 objects, got %d", total)
+	}
+}
+
+func TestGenericPool_StreamRetry(t *testing.T) {
+	var calls int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 1,
+		Max: 2,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) == 2 {
+				return nil, errors.New("transient")
+			}
+			return 1, nil
+		},
+		CloseFunc:  closer,
+		StrictMode: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := pool.Stream(ctx)
+
+	// the second object needs the factory, which fails once
+	v1 := <-stream
+	v2, ok := <-stream
+	if !ok {
+		t.Fatal("[ERR] expected stream to survive a transient factory error")
+	}
+	pool.Release(v1)
+	pool.Release(v2)
+
+	// shutting down with an object pending in the stream must not panic
+	if err := pool.Shutdown(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	cancel()
+	for range stream {
+	}
+}
+
+func TestGenericPool_PauseWaiters(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:            1,
+		Max:            1,
+		FactoryFunc:    factory,
+		CloseFunc:      closer,
+		FailWhenPaused: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	acquired := make(chan error)
+	go func() {
+		_, err := pool.Acquire()
+		acquired <- err
+	}()
+	for pool.Stats().WaiterCount != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// a waiter blocked before the pause fails once the pool is paused
+	pool.Pause()
+	pool.Release(v1)
+	select {
+	case err := <-acquired:
+		if err != ErrPoolPaused {
+			t.Fatalf("[ERR] expected ErrPoolPaused, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("[ERR] expected blocked acquire to fail after pause")
+	}
+	if pool.Len() != 1 {
+		t.Fatalf("[ERR] expected released object to stay idle, len %d", pool.Len())
+	}
+}
+
+func TestGenericPool_PauseShutdown(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool.Pause()
+	acquired := make(chan error)
+	go func() {
+		_, err := pool.Acquire()
+		acquired <- err
+	}()
+	for pool.Stats().WaiterCount != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	pool.Shutdown()
+	select {
+	case err := <-acquired:
+		if err != ErrPoolClosed {
+			t.Fatalf("[ERR] expected ErrPoolClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("[ERR] expected paused acquire to fail on shutdown")
+	}
+}
+
+func TestGenericPool_LessFuncPanic(t *testing.T) {
+	var panics int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         3,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		LessFunc: func(a, b PoolObject) bool {
+			panic("less exploded")
+		},
+		PanicHandler: func(recovered interface{}, hook string) {
+			atomic.AddInt32(&panics, 1)
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := pool.Acquire(); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	if atomic.LoadInt32(&panics) == 0 {
+		t.Fatal("[ERR] expected panic handler to fire")
+	}
+	// no idle object is lost and the lock is not held
+	if stats := pool.Stats(); stats.InUse != 3 || stats.Idle != 0 {
+		t.Fatalf("[ERR] expected 3 objects in use, got %+v", stats)
+	}
+}
+
+func TestNewGenericPool_PartialFill(t *testing.T) {
+	var calls, closed int32
+	_, err := NewGenericPool(&PoolConfig{
+		Min: 3,
+		Max: 3,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) > 2 {
+				return nil, errors.New("backend down")
+			}
+			return 1, nil
+		},
+		CloseFunc: func(interface{}) error {
+			atomic.AddInt32(&closed, 1)
+			return nil
+		},
+		MaxCheckoutTime: time.Second,
+	})
+	if !errors.Is(err, ErrFactoryFunc) {
+		t.Fatalf("[ERR] expected ErrFactoryFunc, got %v", err)
+	}
+	if n := atomic.LoadInt32(&closed); n != 2 {
+		t.Fatalf("[ERR] expected the 2 created objects to be closed, got %d", n)
+	}
+}
+
+func TestGenericPool_AcquireShared(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	// several readers hold the same object at once
+	var wg sync.WaitGroup
+	objs := make([]PoolObject, 3)
+	for i := range objs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := pool.AcquireShared()
+			if err != nil {
+				t.Error("[ERR]", err)
+			}
+			objs[i] = v
+		}(i)
+	}
+	wg.Wait()
+	for _, v := range objs[1:] {
+		if v.id != objs[0].id {
+			t.Fatalf("[ERR] expected shared holders to get the same object, got %d and %d", v.id, objs[0].id)
+		}
+	}
+
+	// no exclusive acquire while readers hold the object
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("[ERR] expected exclusive acquire to wait for readers, got %v", err)
+	}
+	for _, v := range objs {
+		if err := pool.ReleaseShared(v); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	if err := pool.ReleaseShared(objs[0]); err != ErrNotInUse {
+		t.Fatalf("[ERR] expected ErrNotInUse, got %v", err)
+	}
+
+	// an exclusive holder is alone with its object
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	shared := make(chan PoolObject)
+	go func() {
+		s, _ := pool.AcquireShared()
+		shared <- s
+	}()
+	select {
+	case <-shared:
+		t.Fatal("[ERR] expected shared acquire to wait for the exclusive holder")
+	case <-time.After(50 * time.Millisecond):
+	}
+	pool.Release(v)
+	if s := <-shared; s.id != v.id {
+		t.Fatalf("[ERR] expected released object to be shared, got %d", s.id)
+	}
+}
+
+func TestNewGenericPoolWith(t *testing.T) {
+	var calls int32
+	pool, err := NewGenericPoolWith(&PoolConfig{
+		Min: 2,
+		Max: 4,
+		FactoryFunc: func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "created", nil
+		},
+		CloseFunc: closer,
+	}, []interface{}{"a", "b", "c"})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	for _, want := range []string{"a", "b", "c"} {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if v.Object != want {
+			t.Fatalf("[ERR] expected seeded object %s, got %v", want, v.Object)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Fatalf("[ERR] expected factory not to be called, got %d calls", n)
+	}
+	if v, _ := pool.Acquire(); v.Object != "created" {
+		t.Fatalf("[ERR] expected factory object once seeds are used up, got %v", v.Object)
+	}
+
+	if _, err := NewGenericPoolWith(nil, nil); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("[ERR] expected ErrInvalidConfig for a nil config, got %v", err)
+	}
+	_, err = NewGenericPoolWith(intConfig, []interface{}{1, nil})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("[ERR] expected ErrInvalidConfig for a nil object, got %v", err)
+	}
+}
+
+func TestGenericPool_EvictByTag(t *testing.T) {
+	var created int32
+	var closedTags []string
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 4,
+		Max: 5,
+		TaggedFactoryFunc: func() (interface{}, string, error) {
+			n := atomic.AddInt32(&created, 1)
+			if n%2 == 0 {
+				return n, "host-b", nil
+			}
+			return n, "host-a", nil
+		},
+		CloseFunc: func(o interface{}) error {
+			if o.(int32)%2 == 0 {
+				closedTags = append(closedTags, "host-b")
+			} else {
+				closedTags = append(closedTags, "host-a")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// the first object, tagged host-a, is in use during the eviction
+	v1, _ := pool.Acquire()
+	if v1.Tag != "host-a" {
+		t.Fatalf("[ERR] expected tag host-a, got %s", v1.Tag)
+	}
+
+	if n := pool.EvictByTag("host-a"); n != 1 {
+		t.Fatalf("[ERR] expected 1 idle host-a object evicted, got %d", n)
+	}
+	if pool.Len() != 2 {
+		t.Fatalf("[ERR] expected 2 host-b objects to stay idle, got %d", pool.Len())
+	}
+	pool.Release(v1)
+	if pool.Len() != 2 || len(closedTags) != 2 {
+		t.Fatalf("[ERR] expected host-a object in use to be closed on release, closed %v", closedTags)
+	}
+	for _, tag := range closedTags {
+		if tag != "host-a" {
+			t.Fatalf("[ERR] expected only host-a objects closed, got %v", closedTags)
+		}
+	}
+}
+
+func TestGenericPool_AcquireNewerThan(t *testing.T) {
+	var created int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 1,
+		Max: 2,
+		FactoryFunc: func() (interface{}, error) {
+			return int(atomic.AddInt32(&created, 1)), nil
+		},
+		CloseFunc: closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	pool.Release(v1)
+	after := time.Now()
+
+	v2, err := pool.AcquireNewerThan(after)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if v2.Object.(int) != 2 {
+		t.Fatalf("[ERR] expected a newly created object, got %d", v2.Object.(int))
+	}
+	if pool.Len() != 1 {
+		t.Fatalf("[ERR] expected the old object to stay idle, len %d", pool.Len())
+	}
+	// the old object is still fine for callers without a constraint
+	if v, _ := pool.Acquire(); v.Object.(int) != 1 {
+		t.Fatalf("[ERR] expected old object, got %d", v.Object.(int))
+	}
+}
+
+func TestGenericPool_AcquireNewerThanPaused(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:            1,
+		Max:            2,
+		FactoryFunc:    factory,
+		CloseFunc:      closer,
+		FailWhenPaused: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	pool.Pause()
+	if _, err := pool.AcquireNewerThan(time.Now()); err != ErrPoolPaused {
+		t.Fatalf("[ERR] expected ErrPoolPaused, got %v", err)
+	}
+	if stats := pool.Stats(); stats.Total != 1 {
+		t.Fatalf("[ERR] expected no object created while paused, got %+v", stats)
+	}
+	pool.Resume()
+	if _, err := pool.AcquireNewerThan(time.Now()); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+}
+
+func TestGenericPool_LifeTime(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         0,
+		Max:         3,
+		LiftTime:    time.Hour,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	objs := make([]PoolObject, 3)
+	for i := range objs {
+		if objs[i], err = pool.Acquire(); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	// aged in hand, expired on release
+	setObjectCreateTime(&objs[0], aged(time.Hour))
+	pool.Release(objs[0])
+	if n := pool.Len(); n != 0 {
+		t.Fatalf("[ERR] expected expired object closed on release, len %d", n)
+	}
+	// aged while idle, expired on acquire
+	pool.Release(objs[1])
+	pool.Release(objs[2])
+	setObjectCreateTime(&objs[1], aged(time.Hour))
+	seen := make(map[uint64]bool)
+	for i := 0; i < 2; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		seen[v.ID()] = true
+	}
+	if seen[objs[1].ID()] || !seen[objs[2].ID()] {
+		t.Fatalf("[ERR] expected only the aged object replaced, got %v", seen)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_RefreshOnRelease(t *testing.T) {
+	var created int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:      1,
+		Max:      1,
+		LiftTime: time.Hour,
+		FactoryFunc: func() (interface{}, error) {
+			return int(atomic.AddInt32(&created, 1)), nil
+		},
+		CloseFunc:        closer,
+		RefreshOnRelease: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// released past its lifetime, the object is kept
+	for i := 0; i < 3; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if v.Object.(int) != 1 {
+			t.Fatalf("[ERR] expected active object to be kept, got %d", v.Object.(int))
+		}
+		setObjectCreateTime(&v, aged(time.Hour))
+		pool.Release(v)
+	}
+	// left idle past its lifetime, it expires
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool.Release(v)
+	setObjectCreateTime(&v, aged(time.Hour))
+	v, err = pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if v.Object.(int) != 2 {
+		t.Fatalf("[ERR] expected idle object to expire, got %d", v.Object.(int))
+	}
+	t.Log("[SUCC]", atomic.LoadInt32(&created))
+}
+
+func TestGenericPool_Saturation(t *testing.T) {
+	var saturated, desaturated int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:           1,
+		Max:           1,
+		FactoryFunc:   factory,
+		CloseFunc:     closer,
+		OnSaturated:   func() { atomic.AddInt32(&saturated, 1) },
+		OnDesaturated: func() { atomic.AddInt32(&desaturated, 1) },
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if atomic.LoadInt32(&saturated) != 0 {
+		t.Fatal("[ERR] expected no saturation without waiters")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := pool.Acquire()
+			if err != nil {
+				t.Error("[ERR]", err)
+				return
+			}
+			time.Sleep(time.Millisecond)
+			pool.Release(v)
+		}()
+	}
+	// callbacks run after the waiter is counted, give them a moment
+	for pool.Stats().WaiterCount < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&saturated); n != 1 {
+		t.Fatalf("[ERR] expected OnSaturated once, got %d", n)
+	}
+	if atomic.LoadInt32(&desaturated) != 0 {
+		t.Fatal("[ERR] expected no OnDesaturated while acquires wait")
+	}
+
+	pool.Release(v)
+	wg.Wait()
+	if n := atomic.LoadInt32(&desaturated); n != 1 {
+		t.Fatalf("[ERR] expected OnDesaturated once, got %d", n)
+	}
+	t.Log("[SUCC]", saturated, desaturated)
+}
+
+func TestGenericPool_Kill(t *testing.T) {
+	var closed int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc: func(o interface{}) error {
+			atomic.AddInt32(&closed, 1)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	start := time.Now()
+	if err := pool.Kill(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("[ERR] expected Kill to return immediately, took %v", elapsed)
+	}
+	if n := atomic.LoadInt32(&closed); n != 1 {
+		t.Fatalf("[ERR] expected the idle object closed, got %d", n)
+	}
+	if _, err := pool.Acquire(); err != ErrPoolClosed {
+		t.Fatalf("[ERR] expected ErrPoolClosed, got %v", err)
+	}
+
+	// the outstanding object is closed when it comes back
+	if err := pool.Release(v); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := atomic.LoadInt32(&closed); n != 2 {
+		t.Fatalf("[ERR] expected the released object closed, got %d", n)
+	}
+	if stats := pool.Stats(); stats.Total != 0 {
+		t.Fatalf("[ERR] expected no objects left, got %d", stats.Total)
+	}
+	if err := pool.Release(v); err != ErrPoolClosed {
+		t.Fatalf("[ERR] expected ErrPoolClosed on second release, got %v", err)
+	}
+	if err := pool.Kill(); err != ErrPoolClosed {
+		t.Fatalf("[ERR] expected ErrPoolClosed on second kill, got %v", err)
+	}
+}
+
+func TestGenericPool_CreateTimeStats(t *testing.T) {
+	delay := 20 * time.Millisecond
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 2,
+		Max: 3,
+		FactoryFunc: func() (interface{}, error) {
+			time.Sleep(delay)
+			return factory()
+		},
+		CloseFunc: closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := pool.Acquire(); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	stats := pool.Stats()
+	if stats.TotalCreateTime < 3*delay || stats.TotalCreateTime > 3*delay+time.Second {
+		t.Fatalf("[ERR] unexpected total create time %v", stats.TotalCreateTime)
+	}
+	if stats.AvgCreateTime < delay || stats.AvgCreateTime > delay+300*time.Millisecond {
+		t.Fatalf("[ERR] unexpected average create time %v", stats.AvgCreateTime)
+	}
+	t.Log("[SUCC]", stats.TotalCreateTime, stats.AvgCreateTime)
+}
+
+func TestGenericPool_AcquireOrCreate(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, created, err := pool.AcquireOrCreate()
+	if err != nil || created {
+		t.Fatalf("[ERR] expected idle object to be reused, created %v err %v", created, err)
+	}
+	v2, created, err := pool.AcquireOrCreate()
+	if err != nil || !created {
+		t.Fatalf("[ERR] expected a new object, created %v err %v", created, err)
+	}
+	if _, _, err := pool.AcquireOrCreate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("[ERR] expected ErrPoolExhausted, got %v", err)
+	}
+	pool.Release(v1)
+	pool.Release(v2)
+	if stats := pool.Stats(); stats.Idle != 2 || stats.InUse != 0 {
+		t.Fatalf("[ERR] expected both objects idle, got %+v", stats)
+	}
+}
+
+func TestGenericPool_ReleaseOrClose(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var objs []PoolObject
+	for i := 0; i < 3; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		objs = append(objs, v)
+	}
+	// with room, the object is pooled
+	pooled, err := pool.ReleaseOrClose(objs[0])
+	if err != nil || !pooled {
+		t.Fatalf("[ERR] expected object pooled, pooled %v err %v", pooled, err)
+	}
+
+	// the pool is full after shrinking, so the object is closed
+	if err := pool.Resize(1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pooled, err = pool.ReleaseOrClose(objs[1])
+	if err != nil || pooled {
+		t.Fatalf("[ERR] expected object closed, pooled %v err %v", pooled, err)
+	}
+	pooled, err = pool.ReleaseOrClose(objs[2])
+	if err != nil || !pooled {
+		t.Fatalf("[ERR] expected object pooled, pooled %v err %v", pooled, err)
+	}
+	if stats := pool.Stats(); stats.Total != 1 || stats.Idle != 1 {
+		t.Fatalf("[ERR] expected one idle object, got %+v", stats)
+	}
+	if _, err := pool.ReleaseOrClose(objs[2]); err != ErrNotInUse {
+		t.Fatalf("[ERR] expected ErrNotInUse, got %v", err)
+	}
+}
+
+func TestGenericPool_RecycleFunc(t *testing.T) {
+	var recycled int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: requestFactory,
+		RecycleFunc: func(o interface{}) {
+			// back to fasthttp's own pool, not destroyed
+			fasthttp.ReleaseRequest(o.(*fasthttp.Request))
+			atomic.AddInt32(&recycled, 1)
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	if err := pool.Close(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := atomic.LoadInt32(&recycled); n != 1 {
+		t.Fatalf("[ERR] expected closed object recycled, got %d", n)
+	}
+	// the recycled object no longer counts against Max
+	if stats := pool.Stats(); stats.Total != 1 {
+		t.Fatalf("[ERR] expected 1 object, got %d", stats.Total)
+	}
+	v3, created, err := pool.AcquireOrCreate()
+	if err != nil || !created {
+		t.Fatalf("[ERR] expected a new object, created %v err %v", created, err)
+	}
+	pool.Release(v2)
+	pool.Release(v3)
+	if err := pool.Shutdown(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := atomic.LoadInt32(&recycled); n != 3 {
+		t.Fatalf("[ERR] expected all objects recycled, got %d", n)
+	}
+	if stats := pool.Stats(); stats.Total != 0 {
+		t.Fatalf("[ERR] expected no objects left, got %d", stats.Total)
+	}
+}
+
+type resettable struct {
+	n int
+}
+
+func (r *resettable) Reset() {
+	r.n = 0
+}
+
+func TestGenericPool_AutoReset(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: func() (interface{}, error) { return &resettable{}, nil },
+		CloseFunc:   closer,
+		AutoReset:   true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, _ := pool.Acquire()
+	v.Object.(*resettable).n = 42
+	if err := pool.Release(v); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, _ = pool.Acquire()
+	if n := v.Object.(*resettable).n; n != 0 {
+		t.Fatalf("[ERR] expected object reset on release, got %d", n)
+	}
+
+	// a ResetFunc takes precedence over the Reset method
+	pool, err = NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: func() (interface{}, error) { return &resettable{}, nil },
+		CloseFunc:   closer,
+		ResetFunc:   func(o interface{}) { o.(*resettable).n = -1 },
+		AutoReset:   true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, _ = pool.Acquire()
+	v.Object.(*resettable).n = 42
+	pool.Release(v)
+	v, _ = pool.Acquire()
+	if n := v.Object.(*resettable).n; n != -1 {
+		t.Fatalf("[ERR] expected ResetFunc to be used, got %d", n)
+	}
+}
+
+func TestGenericPool_PostCreateFunc(t *testing.T) {
+	var pings, closed int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc: func(o interface{}) error {
+			atomic.AddInt32(&closed, 1)
+			return nil
+		},
+		PostCreateFunc: func(o interface{}) error {
+			// the first handshake fails
+			if atomic.AddInt32(&pings, 1) == 1 {
+				return errors.New("ping failed")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := atomic.LoadInt32(&pings); n != 2 {
+		t.Fatalf("[ERR] expected the ping to be retried, got %d pings", n)
+	}
+	if n := atomic.LoadInt32(&closed); n != 1 {
+		t.Fatalf("[ERR] expected the failed object closed, got %d", n)
+	}
+	if stats := pool.Stats(); stats.Total != 1 || stats.Idle != 1 {
+		t.Fatalf("[ERR] expected one usable object, got %+v", stats)
+	}
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+}
+
+func TestGenericPool_HedgedAcquire(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 1,
+		Max: 2,
+		FactoryFunc: func() (interface{}, error) {
+			time.Sleep(100 * time.Millisecond)
+			return factory()
+		},
+		CloseFunc:     closer,
+		HedgedAcquire: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Release(v1)
+	}()
+
+	// the release is faster than the factory
+	start := time.Now()
+	v2, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+		t.Fatalf("[ERR] expected the released object without waiting for the factory, took %v", elapsed)
+	}
+	if v2.ID() != v1.ID() {
+		t.Fatalf("[ERR] expected the released object, got %d", v2.ID())
+	}
+
+	// the object created meanwhile ends up idle
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats := pool.Stats()
+		if stats.Total == 2 && stats.Idle == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("[ERR] expected the late object to be pooled, got %+v", stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if v3, created, err := pool.AcquireOrCreate(); err != nil || created || v3.ID() == v1.ID() {
+		t.Fatalf("[ERR] expected the late object, got %d created %v err %v", v3.ID(), created, err)
+	}
+}
+
+func TestNewGenericPool_MinZero(t *testing.T) {
+	var created int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 0,
+		Max: 3,
+		FactoryFunc: func() (interface{}, error) {
+			atomic.AddInt32(&created, 1)
+			return factory()
+		},
+		CloseFunc: closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Total != 0 || atomic.LoadInt32(&created) != 0 {
+		t.Fatalf("[ERR] expected an empty pool, got %+v", stats)
+	}
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := atomic.LoadInt32(&created); n != 1 {
+		t.Fatalf("[ERR] expected the first acquire to create an object, got %d", n)
+	}
+}
+
+func TestGenericPool_EvictWhere(t *testing.T) {
+	var closed []int
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         0,
+		Max:         6,
+		FactoryFunc: factory,
+		CloseFunc: func(o interface{}) error {
+			closed = append(closed, o.(int))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var objs []PoolObject
+	for i := 0; i < 6; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		objs = append(objs, v)
+	}
+	for _, v := range objs {
+		pool.Release(v)
+	}
+
+	// evict the objects created first
+	cutoff := objs[3].ID()
+	n := pool.EvictWhere(func(poolObj PoolObject) bool {
+		return poolObj.ID() < cutoff
+	})
+	if n != 3 || pool.Len() != 3 {
+		t.Fatalf("[ERR] expected 3 of 6 objects evicted, got %d, %d left", n, pool.Len())
+	}
+	for i, obj := range closed {
+		if obj != objs[i].Object.(int) {
+			t.Fatalf("[ERR] expected only matching objects closed, got %v", closed)
+		}
+	}
+
+	// a panicking predicate matches nothing
+	n = pool.EvictWhere(func(PoolObject) bool { panic("boom") })
+	if n != 0 || pool.Len() != 3 {
+		t.Fatalf("[ERR] expected nothing evicted, got %d, %d left", n, pool.Len())
+	}
+}
+
+func TestGenericPool_SerialFactory(t *testing.T) {
+	var running, overlaps int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 0,
+		Max: 20,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.AddInt32(&overlaps, 1)
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return factory()
+		},
+		CloseFunc:     closer,
+		SerialFactory: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pool.Acquire(); err != nil {
+				t.Error("[ERR]", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&overlaps); n != 0 {
+		t.Fatalf("[ERR] expected no concurrent factory calls, got %d", n)
+	}
+	if stats := pool.Stats(); stats.InUse != 20 {
+		t.Fatalf("[ERR] expected 20 objects in use, got %d", stats.InUse)
+	}
+}
+
+func TestGenericPool_ReleaseWithError(t *testing.T) {
+	errTimeout := errors.New("read timeout")
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		// timeouts leave the connection usable, anything else breaks it
+		KeepOnError: func(err error) bool { return err == errTimeout },
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	if err := pool.ReleaseWithError(v1, errTimeout); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.ReleaseWithError(v2, errors.New("connection reset")); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Total != 1 || stats.Idle != 1 {
+		t.Fatalf("[ERR] expected only the timed out object to be kept, got %+v", stats)
+	}
+
+	var flagged []PoolObject
+	pool.ForEachIdle(func(poolObj PoolObject) {
+		if poolObj.LastError != nil {
+			flagged = append(flagged, poolObj)
+		}
+	})
+	if len(flagged) != 1 || flagged[0].ID() != v1.ID() || flagged[0].LastError != errTimeout {
+		t.Fatalf("[ERR] expected the kept object flagged with its error, got %+v", flagged)
+	}
+
+	// a clean release clears the error
+	v, _ := pool.Acquire()
+	if v.LastError != errTimeout {
+		t.Fatalf("[ERR] expected LastError on the acquired object, got %v", v.LastError)
+	}
+	pool.Release(v)
+	pool.ForEachIdle(func(poolObj PoolObject) {
+		if poolObj.LastError != nil {
+			t.Fatalf("[ERR] expected LastError cleared, got %v", poolObj.LastError)
+		}
+	})
+}
+
+func TestGenericPool_ShutdownWithResult(t *testing.T) {
+	errClose := errors.New("close failed")
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         4,
+		Max:         5,
+		FactoryFunc: factory,
+		CloseFunc: func(o interface{}) error {
+			// every other object fails to close
+			if o.(int)%2 == 0 {
+				return errClose
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	result := pool.ShutdownWithResult()
+	if result.Closed+result.Failed != 3 || result.Failed < 1 || result.Outstanding != 1 {
+		t.Fatalf("[ERR] unexpected result %+v", result)
+	}
+	if !errors.Is(result.Err, errClose) {
+		t.Fatalf("[ERR] expected close error, got %v", result.Err)
+	}
+	if result := pool.ShutdownWithResult(); result.Err != ErrPoolClosed {
+		t.Fatalf("[ERR] expected ErrPoolClosed, got %v", result.Err)
+	}
+	t.Log("[SUCC]", result)
+}
+
+func TestGenericPool_DedupeCreation(t *testing.T) {
+	var calls int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 0,
+		Max: 20,
+		FactoryFunc: func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(10 * time.Millisecond)
+			return factory()
+		},
+		CloseFunc:      closer,
+		DedupeCreation: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := pool.Acquire()
+			if err != nil {
+				t.Error("[ERR]", err)
+				return
+			}
+			time.Sleep(time.Millisecond)
+			pool.Release(v)
+		}()
+	}
+	wg.Wait()
+	// without dedup, each of the 20 acquires would have created an object
+	if n := atomic.LoadInt32(&calls); n >= 10 {
+		t.Fatalf("[ERR] expected creations to be deduplicated, got %d factory calls", n)
+	}
+	if stats := pool.Stats(); stats.InUse != 0 || stats.Total != int(calls) {
+		t.Fatalf("[ERR] unexpected stats %+v", stats)
+	}
+	if err := pool.Verify(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", calls)
+}
+
+func TestGenericPool_ReleaseContext(t *testing.T) {
+	var closed int32
+	var slow int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc: func(o interface{}) error {
+			atomic.AddInt32(&closed, 1)
+			return nil
+		},
+		ResetFunc: func(o interface{}) {
+			if atomic.LoadInt32(&slow) == 1 {
+				time.Sleep(100 * time.Millisecond)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	// a fast reset pools the object
+	v, _ := pool.Acquire()
+	if err := pool.ReleaseContext(context.Background(), v); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if pool.Len() != 1 {
+		t.Fatalf("[ERR] expected object pooled, len %d", pool.Len())
+	}
+
+	// a slow one gets the object closed
+	atomic.StoreInt32(&slow, 1)
+	v, _ = pool.Acquire()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := pool.ReleaseContext(ctx, v); err != context.DeadlineExceeded {
+		t.Fatalf("[ERR] expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("[ERR] expected return near the deadline, took %v", elapsed)
+	}
+	if stats := pool.Stats(); stats.Total != 0 || stats.Idle != 0 {
+		t.Fatalf("[ERR] expected object not pooled, got %+v", stats)
+	}
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&closed) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected object closed after the reset")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := pool.Release(v); err != ErrNotInUse {
+		t.Fatalf("[ERR] expected ErrNotInUse, got %v", err)
+	}
+}
+
+func TestGenericPool_AcquireByID(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         3,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, _ := pool.Acquire()
+	id := v.ID()
+	if _, ok, err := pool.AcquireByID(id); ok || err != nil {
+		t.Fatalf("[ERR] expected object in use not to be found, ok %v err %v", ok, err)
+	}
+	pool.Release(v)
+
+	// the released object queues behind the others, but is found by its ID
+	v, ok, err := pool.AcquireByID(id)
+	if err != nil || !ok || v.ID() != id {
+		t.Fatalf("[ERR] expected object %d, got %d ok %v err %v", id, v.ID(), ok, err)
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.InUse != 1 {
+		t.Fatalf("[ERR] expected the other objects to stay idle, got %+v", stats)
+	}
+	pool.Close(v)
+	if _, ok, _ := pool.AcquireByID(id); ok {
+		t.Fatal("[ERR] expected closed object not to be found")
+	}
+}
+
+func TestNewGenericPool_ParallelInit(t *testing.T) {
+	start := time.Now()
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 8,
+		Max: 10,
+		FactoryFunc: func() (interface{}, error) {
+			time.Sleep(50 * time.Millisecond)
+			return factory()
+		},
+		CloseFunc:    closer,
+		ParallelInit: 4,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// one by one, that would take 400ms
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Fatalf("[ERR] expected parallel creation, took %v", elapsed)
+	}
+	if stats := pool.Stats(); stats.Total != 8 || stats.Idle != 8 {
+		t.Fatalf("[ERR] expected 8 idle objects, got %+v", stats)
+	}
+	seen := make(map[int]bool)
+	pool.ForEachIdle(func(poolObj PoolObject) {
+		seen[poolObj.Object.(int)] = true
+	})
+	if len(seen) != 8 {
+		t.Fatalf("[ERR] expected 8 distinct objects, got %d", len(seen))
+	}
+}
+
+func TestGenericPool_Overflow(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Max:                 1,
+		FactoryFunc:         factory,
+		CloseFunc:           closer,
+		OverflowMax:         2,
+		OverflowIdleTimeout: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	v1, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if pool.overflow != nil {
+		t.Fatal("[ERR] overflow pool created before the primary was saturated")
+	}
+	// primary is saturated, the burst is served by the overflow pool
+	burst := make([]PoolObject, 2)
+	for i := range burst {
+		if burst[i], err = pool.Acquire(); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	if stats := pool.overflow.Stats(); stats.InUse != 2 {
+		t.Fatalf("[ERR] expected 2 overflow objects in use, got %+v", stats)
+	}
+	for _, v := range burst {
+		if err := pool.Release(v); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	if err := pool.Release(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Total != 1 || stats.Idle != 1 {
+		t.Fatalf("[ERR] expected primary to keep its object, got %+v", stats)
+	}
+
+	// the overflow objects are closed soon after the burst
+	deadline := time.Now().Add(time.Second)
+	for pool.overflow.Stats().Total > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("[ERR] overflow objects not reaped, %+v", pool.overflow.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_FallbackFactoryFunc(t *testing.T) {
+	var primaryDown int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 1,
+		Max: 2,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.LoadInt32(&primaryDown) == 1 {
+				return nil, errors.New("primary down")
+			}
+			return "primary", nil
+		},
+		FallbackFactoryFunc: func() (interface{}, error) { return "local", nil },
+		CloseFunc:           closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, err := pool.Acquire()
+	if err != nil || v1.Object != "primary" || v1.Fallback {
+		t.Fatalf("[ERR] expected primary object, got %+v %v", v1, err)
+	}
+
+	atomic.StoreInt32(&primaryDown, 1)
+	v2, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if v2.Object != "local" || !v2.Fallback {
+		t.Fatalf("[ERR] expected fallback object, got %+v", v2)
+	}
+	// fallback objects can be told apart once the primary is back
+	pool.Release(v2)
+	atomic.StoreInt32(&primaryDown, 0)
+	if n := pool.EvictWhere(func(o PoolObject) bool { return o.Fallback }); n != 1 {
+		t.Fatalf("[ERR] expected 1 fallback object evicted, got %d", n)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_ShutdownContext(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.ShutdownContext(context.Background())
+	}()
+	for {
+		pool.Lock()
+		draining := pool.draining
+		pool.Unlock()
+		if draining {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if _, err := pool.Acquire(); err != ErrPoolDraining {
+		t.Fatalf("[ERR] expected ErrPoolDraining, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("[ERR] expected acquire to fail fast, took %v", elapsed)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("[ERR] shutdown returned with an object outstanding: %v", err)
+	default:
+	}
+
+	// the outstanding object completes the drain
+	if err := pool.Release(v); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Total != 0 {
+		t.Fatalf("[ERR] expected all objects closed, got %+v", stats)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_ReplaceBefore(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:           2,
+		Max:           2,
+		LiftTime:      200 * time.Millisecond,
+		ReplaceBefore: 150 * time.Millisecond,
+		FactoryFunc:   factory,
+		CloseFunc:     closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	events := pool.Events()
+
+	// objects are swapped well before they expire, within about 125ms, so
+	// acquires never get close to an expired one
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if age := time.Duration(time.Now().UnixNano() - v.CreateTime); age >= 180*time.Millisecond {
+			t.Fatalf("[ERR] acquired object aged %v", age)
+		}
+		pool.Release(v)
+		time.Sleep(5 * time.Millisecond)
+	}
+	replaced := 0
+	for len(events) > 0 {
+		if e := <-events; e.Type == EventExpired {
+			replaced++
+		}
+	}
+	if replaced == 0 {
+		t.Fatal("[ERR] expected objects to be replaced")
+	}
+	if err := pool.Verify(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", replaced)
+}
+
+func TestPoolObject_Clone(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v.Meta["host"] = "a"
+	clone := v.Clone()
+	clone.Meta["host"] = "b"
+	clone.Meta["extra"] = "c"
+	pool.Lock()
+	meta := pool.inUse[v.ID()].poolObj.Meta
+	pool.Unlock()
+	if meta["host"] != "a" || len(meta) != 1 {
+		t.Fatalf("[ERR] expected pool's copy unaffected by the clone, got %v", meta)
+	}
+
+	// idle objects are inspected through clones
+	pool.Release(v)
+	pool.ForEachIdle(func(o PoolObject) {
+		o.Meta["host"] = "b"
+	})
+	v, _ = pool.Acquire()
+	if v.Meta["host"] != "a" {
+		t.Fatalf("[ERR] expected metadata kept, got %v", v.Meta)
+	}
+	t.Log("[SUCC]", v.Meta)
+}
+
+func TestGenericPool_ReadyFunc(t *testing.T) {
+	var down int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		ReadyFunc:   func() bool { return atomic.LoadInt32(&down) == 0 },
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	atomic.StoreInt32(&down, 1)
+	// the warm object is still handed out
+	warm, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// a cold acquire doesn't reach the factory
+	if _, err := pool.Acquire(); err != ErrDependencyUnavailable {
+		t.Fatalf("[ERR] expected ErrDependencyUnavailable, got %v", err)
+	}
+	pool.Release(warm)
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	atomic.StoreInt32(&down, 0)
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Total != 2 {
+		t.Fatalf("[ERR] expected a new object once ready, got %+v", stats)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_ReleaseWrongPool(t *testing.T) {
+	poolA, err := NewGenericPool(&PoolConfig{Min: 1, Max: 1, FactoryFunc: factory, CloseFunc: closer})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	poolB, err := NewGenericPool(&PoolConfig{Min: 1, Max: 1, FactoryFunc: factory, CloseFunc: closer})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, err := poolA.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// same id in both pools, the receipt tells them apart
+	w, _ := poolB.Acquire()
+	if err := poolB.Release(v); err != ErrWrongPool {
+		t.Fatalf("[ERR] expected ErrWrongPool, got %v", err)
+	}
+	if err := poolB.Release(w); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := poolA.Release(v); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", poolA.Stats(), poolB.Stats())
+}
+
+// recordingLogger keeps what is logged
+type recordingLogger struct {
+	sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestGenericPool_SlowAcquireThreshold(t *testing.T) {
+	logger := &recordingLogger{}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:                  1,
+		Max:                  1,
+		FactoryFunc:          factory,
+		CloseFunc:            closer,
+		Logger:               logger,
+		SlowAcquireThreshold: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pool.Release(v)
+	}()
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	logger.Lock()
+	defer logger.Unlock()
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "acquire waited") ||
+		!strings.Contains(logger.lines[0], "utilization 1.00") {
+		t.Fatalf("[ERR] expected one slow acquire warning, got %q", logger.lines)
+	}
+	t.Log("[SUCC]", logger.lines)
+}
+
+func TestGenericPool_AutoShutdownAfter(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:               1,
+		Max:               2,
+		FactoryFunc:       factory,
+		CloseFunc:         closer,
+		AutoShutdownAfter: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// an object in use keeps the pool alive
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if pool.IsClosed() {
+		t.Fatal("[ERR] expected pool kept open with an object in use")
+	}
+	pool.Release(v)
+
+	deadline := time.Now().Add(time.Second)
+	for !pool.IsClosed() {
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected inactive pool to shut itself down")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := pool.Acquire(); err != ErrPoolClosed {
+		t.Fatalf("[ERR] expected ErrPoolClosed, got %v", err)
+	}
+	if stats := pool.Stats(); stats.Total != 0 {
+		t.Fatalf("[ERR] expected all objects closed, got %+v", stats)
+	}
+	t.Log("[SUCC]", pool.IsClosed())
+}
+
+// failingOn returns a factory failing on the given calls, counted from 1
+func failingOn(calls *int32, failing ...int32) FactoryFunc {
+	return func() (interface{}, error) {
+		n := atomic.AddInt32(calls, 1)
+		for _, f := range failing {
+			if n == f {
+				return nil, errors.New("factory failed")
+			}
+		}
+		return int(n), nil
+	}
+}
+
+func TestGenericPool_InitContinue(t *testing.T) {
+	var calls int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         3,
+		Max:         3,
+		FactoryFunc: failingOn(&calls, 2, 3, 4),
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := pool.Len(); n != 3 || atomic.LoadInt32(&calls) != 6 {
+		t.Fatalf("[ERR] expected 3 objects after 6 calls, got %d after %d", n, calls)
+	}
+}
+
+func TestGenericPool_InitFailFast(t *testing.T) {
+	var calls int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:               3,
+		Max:               3,
+		FactoryFunc:       failingOn(&calls, 2),
+		CloseFunc:         closer,
+		InitFailurePolicy: InitFailFast,
+	})
+	if !errors.Is(err, ErrFactoryFunc) {
+		t.Fatalf("[ERR] expected ErrFactoryFunc, got %v", err)
+	}
+	if n := pool.Stats().Total; n != 0 || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("[ERR] expected 0 objects after 2 calls, got %d after %d", n, calls)
+	}
+}
+
+func TestGenericPool_InitRetrySlot(t *testing.T) {
+	var calls int32
+	// two failures in a row are retried
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:               3,
+		Max:               3,
+		FactoryFunc:       failingOn(&calls, 2, 3),
+		CloseFunc:         closer,
+		InitFailurePolicy: InitRetrySlot,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := pool.Len(); n != 3 || atomic.LoadInt32(&calls) != 5 {
+		t.Fatalf("[ERR] expected 3 objects after 5 calls, got %d after %d", n, calls)
+	}
+
+	// three are not, which InitContinue would have tolerated
+	calls = 0
+	pool, err = NewGenericPool(&PoolConfig{
+		Min:               3,
+		Max:               3,
+		FactoryFunc:       failingOn(&calls, 2, 3, 4),
+		CloseFunc:         closer,
+		InitFailurePolicy: InitRetrySlot,
+	})
+	if !errors.Is(err, ErrFactoryFunc) {
+		t.Fatalf("[ERR] expected ErrFactoryFunc, got %v", err)
+	}
+	if n := pool.Stats().Total; n != 0 || atomic.LoadInt32(&calls) != 4 {
+		t.Fatalf("[ERR] expected 0 objects after 4 calls, got %d after %d", n, calls)
+	}
+}
+
+func TestGenericPool_StrictFIFO(t *testing.T) {
+	if _, err := NewGenericPool(&PoolConfig{
+		Max:         1,
+		FactoryFunc: factory,
+		StrictFIFO:  true,
+		LessFunc:    func(a, b PoolObject) bool { return true },
+	}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("[ERR] expected ErrInvalidConfig, got %v", err)
+	}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         3,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		StrictFIFO:  true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	// inspection taking idle objects out and back meanwhile
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				pool.ForEachIdle(func(PoolObject) {})
+				pool.EvictWhere(func(PoolObject) bool { return false })
+			}
+		}()
+	}
+	for i := 0; i < 300; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if want := uint64(i%3 + 1); v.ID() != want {
+			t.Fatalf("[ERR] acquire %d: expected object %d, got %d", i, want, v.ID())
+		}
+		pool.Release(v)
+	}
+	close(stop)
+	wg.Wait()
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_FactoryFuncStateful(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 4,
+		Max: 6,
+		FactoryFuncStateful: func(stats PoolStats) (interface{}, error) {
+			// the object being created is the Total-th
+			if stats.Total%2 == 1 {
+				return "backend-a", nil
+			}
+			return "backend-b", nil
+		},
+		CloseFunc: closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	count := make(map[interface{}]int)
+	for i := 0; i < 6; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		count[v.Object]++
+	}
+	if count["backend-a"] != 3 || count["backend-b"] != 3 {
+		t.Fatalf("[ERR] expected objects balanced across backends, got %v", count)
+	}
+	t.Log("[SUCC]", count)
+}
+
+func TestGenericPool_FactoryFuncStatefulRefill(t *testing.T) {
+	stateful := func(stats PoolStats) (interface{}, error) {
+		return stats.Total, nil
+	}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:                 2,
+		Max:                 4,
+		FactoryFuncStateful: stateful,
+		CloseFunc:           closer,
+		SerialFactory:       true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	// both refill the pool, and must not hold the lock the factory's Stats
+	// call takes
+	done := make(chan error)
+	go func() {
+		if err := pool.Recycle(); err != nil {
+			done <- err
+			return
+		}
+		done <- pool.Reconfigure(&PoolConfig{
+			Min:                 4,
+			Max:                 4,
+			FactoryFuncStateful: stateful,
+			CloseFunc:           closer,
+			SerialFactory:       true,
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("[ERR] refilling with a stateful factory deadlocked")
+	}
+	if stats := pool.Stats(); stats.Total != 4 || stats.Idle != 4 {
+		t.Fatalf("[ERR] expected 4 idle objects, got %+v", stats)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_ExhaustedRetryAfter(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	// objects are held for about 40ms
+	for i := 0; i < 2; i++ {
+		v, _, _ := pool.AcquireOrCreate()
+		time.Sleep(40 * time.Millisecond)
+		pool.Release(v)
+	}
+	v1, _, _ := pool.AcquireOrCreate()
+	v2, _, _ := pool.AcquireOrCreate()
+	_, _, err = pool.AcquireOrCreate()
+	var exhausted *ExhaustedError
+	if !errors.As(err, &exhausted) || !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("[ERR] expected ExhaustedError, got %v", err)
+	}
+	// two objects in use, one is released every 20ms or so
+	if exhausted.RetryAfter < 15*time.Millisecond || exhausted.RetryAfter > time.Second {
+		t.Fatalf("[ERR] implausible RetryAfter %v", exhausted.RetryAfter)
+	}
+	pool.Release(v1)
+	pool.Release(v2)
+	t.Log("[SUCC]", err)
+}
+
+func TestGenericPool_DeferReset(t *testing.T) {
+	gate := make(chan struct{})
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: func() (interface{}, error) { return &resettable{}, nil },
+		CloseFunc:   closer,
+		ResetFunc: func(o interface{}) {
+			// held until the test lets it go
+			gate <- struct{}{}
+			o.(*resettable).n = 0
+		},
+		DeferReset: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	state := func() (idle, resetting int) {
+		pool.Lock()
+		defer pool.Unlock()
+		return pool.idleLen(), pool.resetting
+	}
+	for i := 0; i < 3; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if n := v.Object.(*resettable).n; n != 0 {
+			t.Fatalf("[ERR] acquire %d: expected a reset object, got %d", i, n)
+		}
+		v.Object.(*resettable).n = 42
+		// returns while the reset is held
+		if err := pool.Release(v); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if idle, resetting := state(); idle != 0 || resetting != 1 {
+			t.Fatalf("[ERR] expected the object awaiting its reset, idle %d resetting %d", idle, resetting)
+		}
+		<-gate
+		deadline := time.Now().Add(time.Second)
+		for idle, _ := state(); idle != 1; idle, _ = state() {
+			if time.Now().After(deadline) {
+				t.Fatal("[ERR] expected the object pooled once reset")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if err := pool.Verify(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_FactoryFuncCtx(t *testing.T) {
+	var closed int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 0,
+		Max: 1,
+		FactoryFuncCtx: func(ctx context.Context) (interface{}, error) {
+			// half connected when cancelled
+			<-ctx.Done()
+			return "partial", ctx.Err()
+		},
+		CloseFunc: func(o interface{}) error {
+			if o == "partial" {
+				atomic.AddInt32(&closed, 1)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.AcquireContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("[ERR] expected DeadlineExceeded, got %v", err)
+	}
+	if n := atomic.LoadInt32(&closed); n != 1 {
+		t.Fatalf("[ERR] expected the partial object closed, got %d closes", n)
+	}
+	if stats := pool.Stats(); stats.Total != 0 {
+		t.Fatalf("[ERR] expected no object left, got %+v", stats)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_AcquireScoped(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	_, release, err := pool.AcquireScoped()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	release()
+	if stats := pool.Stats(); stats.InUse != 0 || stats.Idle != 1 {
+		t.Fatalf("[ERR] expected the object released, got %+v", stats)
+	}
+
+	// a second call must not release the object acquired meanwhile
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	release()
+	if stats := pool.Stats(); stats.InUse != 1 || stats.Idle != 0 {
+		t.Fatalf("[ERR] expected the object to stay in use, got %+v", stats)
+	}
+	if err := pool.Release(v); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", pool.Len())
+}
+
+func TestGenericPool_CancelWaiters(t *testing.T) {
+	for _, fifo := range []bool{false, true} {
+		testCancelWaiters(t, fifo)
+	}
+}
+
+func TestGenericPool_CancelHedgedWaiters(t *testing.T) {
+	unblock := make(chan struct{})
+	pool, err := NewGenericPool(&PoolConfig{
+		Max: 1,
+		FactoryFunc: func() (interface{}, error) {
+			<-unblock
+			return factory()
+		},
+		CloseFunc:     closer,
+		HedgedAcquire: true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := pool.Acquire()
+		errs <- err
+	}()
+	// waiting on the factory and for a release at once
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().WaiterCount < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected a hedged waiter")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	pool.CancelWaiters(nil)
+	select {
+	case err := <-errs:
+		if err != ErrAcquireCancelled {
+			t.Fatalf("[ERR] expected ErrAcquireCancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("[ERR] expected the hedged waiter to be cancelled promptly")
+	}
+
+	// the object created meanwhile is pooled
+	close(unblock)
+	deadline = time.Now().Add(time.Second)
+	for pool.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("[ERR] expected the late object pooled, got %+v", pool.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func testCancelWaiters(t *testing.T, fifo bool) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		// acquires queue for their turn before waiting for an object
+		StrictFIFO: fifo,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	v, _ := pool.Acquire()
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := pool.Acquire()
+			errs <- err
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().WaiterCount < 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected 5 waiters")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	pool.CancelWaiters(nil)
+	for i := 0; i < 5; i++ {
+		select {
+		case err := <-errs:
+			if err != ErrAcquireCancelled {
+				t.Fatalf("[ERR] expected ErrAcquireCancelled, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("[ERR] expected waiters to be cancelled promptly")
+		}
+	}
+
+	// the pool keeps working
+	pool.Release(v)
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_CloseIdleObject(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	if err := pool.Close(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if total := pool.Stats().Total; total != 1 {
+		t.Fatalf("[ERR] expected the closed object gone, %d left", total)
+	}
+
+	// an idle object is left alone
+	pool.Release(v2)
+	if err := pool.Close(v2); err != ErrObjectIdle || !errors.Is(err, ErrNotInUse) {
+		t.Fatalf("[ERR] expected ErrObjectIdle, got %v", err)
+	}
+	if stats := pool.Stats(); stats.Idle != 1 || stats.Total != 1 {
+		t.Fatalf("[ERR] expected the idle object kept, got %+v", stats)
+	}
+	if err := pool.Verify(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+}
+
+func TestGenericPool_OnMinReached(t *testing.T) {
+	var reached int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:          2,
+		Max:          3,
+		FactoryFunc:  factory,
+		CloseFunc:    closer,
+		OnMinReached: func() { atomic.AddInt32(&reached, 1) },
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	pool.Close(v1)
+	pool.Close(v2)
+
+	// refilling to Min fires once, going beyond it doesn't
+	var objs []PoolObject
+	for i := 0; i < 3; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		objs = append(objs, v)
+		if i == 0 {
+			time.Sleep(20 * time.Millisecond)
+			if n := atomic.LoadInt32(&reached); n != 0 {
+				t.Fatalf("[ERR] expected OnMinReached not fired below Min, fired %d times", n)
+			}
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := atomic.LoadInt32(&reached); n != 1 {
+		t.Fatalf("[ERR] expected OnMinReached fired once, fired %d times", n)
+	}
+	for _, v := range objs {
+		pool.Release(v)
+	}
+	t.Log("[SUCC]", atomic.LoadInt32(&reached))
+}
+
+func TestGenericPool_OrderLRU(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         3,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		Ordering:    OrderLRU,
+		Clock:       clock,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var objs []PoolObject
+	for i := 0; i < 3; i++ {
+		v, _ := pool.Acquire()
+		objs = append(objs, v)
+	}
+	// released at staggered times, the middle one first
+	for _, i := range []int{1, 2, 0} {
+		clock.Advance(time.Second)
+		pool.Release(objs[i])
+	}
+	for _, i := range []int{1, 2, 0} {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		if v.ID() != objs[i].ID() {
+			t.Fatalf("[ERR] expected object %d idle the longest, got %d", objs[i].ID(), v.ID())
+		}
+	}
+	if _, err := NewGenericPool(&PoolConfig{
+		Max:         1,
+		FactoryFunc: factory,
+		Ordering:    OrderLRU,
+		LessFunc:    func(a, b PoolObject) bool { return false },
+	}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("[ERR] expected ErrInvalidConfig combining Ordering and LessFunc, got %v", err)
+	}
+}
+
+func TestGenericPool_ShutdownDuringBackgroundCreate(t *testing.T) {
+	// a factory ignoring ctx is awaited, and its object closed
+	var calls, closed int32
+	unblock := make(chan struct{})
+	pool, err := NewGenericPool(&PoolConfig{
+		Max: 4,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) > 1 {
+				<-unblock
+			}
+			return factory()
+		},
+		CloseFunc: func(interface{}) error {
+			atomic.AddInt32(&closed, 1)
+			return nil
+		},
+		CreateAheadFactor: 1,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, _ := pool.Acquire()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected an object created ahead")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := pool.Shutdown(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	close(unblock)
+	for atomic.LoadInt32(&closed) < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected the object created after shutdown to be closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := pool.Release(v); err != ErrPoolClosed {
+		t.Fatalf("[ERR] expected ErrPoolClosed, got %v", err)
+	}
+
+	// a factory taking ctx is cancelled
+	cancelled := make(chan struct{})
+	calls = 0
+	pool, err = NewGenericPool(&PoolConfig{
+		Max: 4,
+		FactoryFuncCtx: func(ctx context.Context) (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return factory()
+			}
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		},
+		CloseFunc:         closer,
+		CreateAheadFactor: 1,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool.Acquire()
+	for atomic.LoadInt32(&calls) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	pool.Shutdown()
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("[ERR] expected the background factory call to be cancelled")
+	}
+}
+
+func TestGenericPool_AcquireWatched(t *testing.T) {
+	logger := &recordingLogger{}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		Logger:      logger,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	logged := func() int {
+		logger.Lock()
+		defer logger.Unlock()
+		return len(logger.lines)
+	}
+
+	// released in time
+	_, release, err := pool.AcquireWatched(20 * time.Millisecond)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	release()
+	time.Sleep(40 * time.Millisecond)
+	if n := logged(); n != 0 || pool.OverdueCount() != 0 {
+		t.Fatalf("[ERR] expected no warning, got %v", logger.lines)
+	}
+
+	// held too long
+	_, release, _ = pool.AcquireWatched(20 * time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	release()
+	if n := logged(); n != 1 || pool.OverdueCount() != 1 {
+		t.Fatalf("[ERR] expected one warning, got %v", logger.lines)
+	}
+	t.Log("[SUCC]", logger.lines)
+}
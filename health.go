@@ -0,0 +1,123 @@
+package pool
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	healthWindow       = time.Minute // factory calls older than this don't count for Health
+	healthSamples      = 32          // factory calls remembered for Health
+	degradedErrorRate  = 0.1         // factory error rate making the pool Degraded
+	unhealthyErrorRate = 0.5         // factory error rate making the pool Unhealthy
+
+	defaultErrorHistorySize = 16 // factory errors kept for RecentErrors
+)
+
+type HealthStatus int
+
+const (
+	Healthy   HealthStatus = iota
+	Degraded               // saturated, or some factory calls fail
+	Unhealthy              // shut down, most factory calls fail, or Max acquires are waiting
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case Healthy:
+		return "healthy"
+	case Degraded:
+		return "degraded"
+	case Unhealthy:
+		return "unhealthy"
+	}
+	return "unknown"
+}
+
+// TimedError is a factory error, and when it happened.
+type TimedError struct {
+	Time time.Time
+	Err  error
+}
+
+// factoryOutcomes remembers the results of the last factory calls.
+type factoryOutcomes struct {
+	sync.Mutex
+	at     [healthSamples]time.Time
+	failed [healthSamples]bool
+	next   int
+
+	errs     []TimedError // ring of the last errors, oldest at errsNext once full
+	errsNext int
+}
+
+// recordFactory remembers the result of a factory call for Health, and the
+// error for RecentErrors.
+func (p *GenericPool) recordFactory(err error) {
+	o := &p.outcomes
+	now := p.now()
+	o.Lock()
+	defer o.Unlock()
+	o.at[o.next] = now
+	o.failed[o.next] = err != nil
+	o.next = (o.next + 1) % healthSamples
+	if err == nil {
+		return
+	}
+	if len(o.errs) < p.errHistory {
+		o.errs = append(o.errs, TimedError{Time: now, Err: err})
+		return
+	}
+	o.errs[o.errsNext] = TimedError{Time: now, Err: err}
+	o.errsNext = (o.errsNext + 1) % len(o.errs)
+}
+
+// RecentErrors returns the last factory errors, up to ErrorHistorySize of
+// them, oldest first.
+func (p *GenericPool) RecentErrors() []TimedError {
+	o := &p.outcomes
+	o.Lock()
+	defer o.Unlock()
+	errs := make([]TimedError, 0, len(o.errs))
+	errs = append(errs, o.errs[o.errsNext:]...)
+	return append(errs, o.errs[:o.errsNext]...)
+}
+
+// factoryErrorRate returns the share of failed factory calls within
+// healthWindow, and how many calls there were.
+func (p *GenericPool) factoryErrorRate() (rate float64, calls int) {
+	o := &p.outcomes
+	o.Lock()
+	defer o.Unlock()
+	since := p.now().Add(-healthWindow)
+	failures := 0
+	for i, at := range o.at {
+		if at.IsZero() || at.Before(since) {
+			continue
+		}
+		calls++
+		if o.failed[i] {
+			failures++
+		}
+	}
+	if calls == 0 {
+		return 0, 0
+	}
+	return float64(failures) / float64(calls), calls
+}
+
+// Health sums up the state of the pool as a readiness signal, from the
+// factory error rate of the last minute, whether the pool is saturated, and
+// how many acquires are waiting.
+func (p *GenericPool) Health() HealthStatus {
+	rate, _ := p.factoryErrorRate()
+	p.Lock()
+	defer p.Unlock()
+	switch {
+	case p.closed || p.draining || rate >= unhealthyErrorRate || p.waiters >= p.maxCap:
+		return Unhealthy
+	case rate >= degradedErrorRate || p.waiters > 0 || (p.curNum >= p.maxCap && p.idleLen() == 0):
+		return Degraded
+	}
+	return Healthy
+}
@@ -0,0 +1,116 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGenericPool_Health(t *testing.T) {
+	var failing int32
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 0,
+		Max: 2,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.LoadInt32(&failing) == 1 {
+				return nil, errors.New("backend down")
+			}
+			return factory()
+		},
+		CloseFunc: closer,
+		Clock:     clock,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	expect := func(want HealthStatus) {
+		t.Helper()
+		if got := pool.Health(); got != want {
+			t.Fatalf("[ERR] expected %v, got %v", want, got)
+		}
+	}
+	expect(Healthy)
+
+	// saturated
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	expect(Degraded)
+
+	// waiters for all objects
+	for i := 0; i < 2; i++ {
+		go func() {
+			if v, err := pool.Acquire(); err == nil {
+				pool.Release(v)
+			}
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().WaiterCount < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("[ERR] expected 2 waiters")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	expect(Unhealthy)
+	pool.Release(v1)
+	pool.Release(v2)
+	for pool.Stats().WaiterCount > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	pool.CloseIdle()
+	expect(Healthy)
+
+	// 1 of 3 factory calls failing, then 2 of 4
+	atomic.StoreInt32(&failing, 1)
+	if _, err := pool.Acquire(); err == nil {
+		t.Fatal("[ERR] expected the factory to fail")
+	}
+	expect(Degraded)
+	pool.Acquire()
+	expect(Unhealthy)
+
+	// the failures are forgotten after a while
+	clock.Advance(2 * healthWindow)
+	expect(Healthy)
+	pool.Shutdown()
+	expect(Unhealthy)
+	t.Log("[SUCC]")
+}
+
+func TestGenericPool_RecentErrors(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	var calls int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Max: 1,
+		FactoryFunc: func() (interface{}, error) {
+			return nil, fmt.Errorf("failure %d", atomic.AddInt32(&calls, 1))
+		},
+		CloseFunc:        closer,
+		Clock:            clock,
+		ErrorHistorySize: 3,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if errs := pool.RecentErrors(); len(errs) != 0 {
+		t.Fatalf("[ERR] expected no errors yet, got %v", errs)
+	}
+	for i := 0; i < 5; i++ {
+		clock.Advance(time.Second)
+		pool.Acquire()
+	}
+	errs := pool.RecentErrors()
+	if len(errs) != 3 {
+		t.Fatalf("[ERR] expected the last 3 errors, got %v", errs)
+	}
+	for i, e := range errs {
+		want := fmt.Sprintf("failure %d", i+3)
+		if e.Err.Error() != want || !e.Time.Equal(time.Unix(1000+int64(i)+3, 0)) {
+			t.Fatalf("[ERR] expected %q at %d, got %q at %v", want, 1003+i, e.Err, e.Time.Unix())
+		}
+	}
+	t.Log("[SUCC]", errs)
+}
@@ -0,0 +1,167 @@
+package pool
+
+import "container/heap"
+
+// idleHeap keeps idle objects ordered by a LessFunc, best one first.
+type idleHeap struct {
+	objs []PoolObject
+	less LessFunc
+}
+
+func (h *idleHeap) Len() int           { return len(h.objs) }
+func (h *idleHeap) Less(i, j int) bool { return h.less(h.objs[i], h.objs[j]) }
+func (h *idleHeap) Swap(i, j int)      { h.objs[i], h.objs[j] = h.objs[j], h.objs[i] }
+func (h *idleHeap) Push(x interface{}) { h.objs = append(h.objs, x.(PoolObject)) }
+
+func (h *idleHeap) Pop() interface{} {
+	n := len(h.objs)
+	obj := h.objs[n-1]
+	h.objs = h.objs[:n-1]
+	return obj
+}
+
+// idleLess returns how idle objects are ordered in the heap, nil if they are
+// kept in the channel.
+func (config *PoolConfig) idleLess() LessFunc {
+	if config.LessFunc != nil {
+		return config.LessFunc
+	}
+	if config.Ordering == OrderLRU {
+		return lessLRU
+	}
+	return nil
+}
+
+// ForEachIdle calls fn on a clone of each idle object, in no particular order.
+// fn gets a snapshot taken under the lock, so it may call the pool, and
+// objects may be acquired meanwhile.
+func (p *GenericPool) ForEachIdle(fn func(PoolObject)) {
+	p.Lock()
+	idle := p.idleObjects()
+	p.Unlock()
+	for _, poolObj := range idle {
+		fn(poolObj.Clone())
+	}
+}
+
+// The idle objects live in the pool channel, or in the sorted heap when a
+// LessFunc is configured. The functions below must be called with the lock
+// held.
+
+// number of idle objects
+func (p *GenericPool) idleLen() int {
+	if p.sorted != nil {
+		return p.sorted.Len()
+	}
+	return len(p.pool)
+}
+
+// putIdle adds an object to the idle objects, and reports false if there is no
+// room left for it.
+func (p *GenericPool) putIdle(poolObj PoolObject) bool {
+	if poolObj.idleSince == 0 {
+		// kept when an idle object is only taken out and put back
+		poolObj.idleSince = p.now().UnixNano()
+	}
+	if p.sorted == nil {
+		select {
+		case p.pool <- poolObj:
+			p.notifyCapacity()
+			return true
+		default:
+			return false
+		}
+	}
+	if p.sorted.Len() >= p.maxCap {
+		return false
+	}
+	// on a LessFunc panic the object is still appended, just out of order
+	p.protect("LessFunc", func() error {
+		heap.Push(p.sorted, poolObj)
+		return nil
+	})
+	// waiters don't block on the heap, let them look at it
+	p.broadcast()
+	return true
+}
+
+// takeIdle takes an idle object without waiting, the best one by lessFunc if
+// configured.
+func (p *GenericPool) takeIdle() (poolObj PoolObject, ok bool) {
+	if p.sorted == nil {
+		select {
+		case poolObj, ok = <-p.pool:
+			// a closed channel has nothing left
+			return poolObj, ok
+		default:
+			return poolObj, false
+		}
+	}
+	n := p.sorted.Len()
+	if n == 0 {
+		return poolObj, false
+	}
+	err := p.protect("LessFunc", func() error {
+		poolObj = heap.Pop(p.sorted).(PoolObject)
+		return nil
+	})
+	if err != nil {
+		// heap.Pop moved the best object to the end before calling LessFunc
+		poolObj = p.sorted.Pop().(PoolObject)
+	}
+	return poolObj, true
+}
+
+// idleObjects returns all idle objects, leaving them idle. A shut down pool
+// has none.
+func (p *GenericPool) idleObjects() []PoolObject {
+	if p.closed {
+		return nil
+	}
+	idle := p.drainIdle()
+	for _, poolObj := range idle {
+		if !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+	}
+	return idle
+}
+
+// isIdle reports whether the object with id is idle.
+func (p *GenericPool) isIdle(id uint64) bool {
+	for _, poolObj := range p.idleObjects() {
+		if poolObj.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// evictable reports whether an idle object has been idle long enough to be
+// closed by scaling down.
+func (p *GenericPool) evictable(poolObj PoolObject) bool {
+	return p.now().UnixNano()-poolObj.idleSince >= int64(p.minIdleBeforeEvict)
+}
+
+// drainIdle removes and returns all idle objects.
+func (p *GenericPool) drainIdle() []PoolObject {
+	if p.sorted != nil {
+		objs := p.sorted.objs
+		p.sorted.objs = nil
+		return objs
+	}
+	objs := make([]PoolObject, 0, len(p.pool))
+	for {
+		// waiters receive without the lock, so the channel may run dry early
+		select {
+		case poolObj, ok := <-p.pool:
+			if !ok {
+				// closed by shutdown
+				return objs
+			}
+			objs = append(objs, poolObj)
+		default:
+			return objs
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+)
+
+// AcquireLabeled is AcquireContext, but a timeout or cancellation error names
+// label and the pool state at that moment, to make timeout logs actionable.
+// The error still matches ctx.Err() in errors.Is.
+func (p *GenericPool) AcquireLabeled(ctx context.Context, label string) (PoolObject, error) {
+	poolObj, err := p.AcquireContext(ctx)
+	if err != nil && err == ctx.Err() {
+		stats := p.Stats()
+		err = fmt.Errorf("acquire %q: %w (idle %d, in use %d, waiters %d, max %d)",
+			label, err, stats.Idle, stats.InUse, stats.WaiterCount, stats.Max)
+	}
+	return poolObj, err
+}
@@ -0,0 +1,37 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenericPool_AcquireLabeled(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	pool.Acquire()
+	pool.Acquire()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.AcquireLabeled(ctx, "checkout-handler")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("[ERR] expected DeadlineExceeded, got %v", err)
+	}
+	for _, want := range []string{"checkout-handler", "idle 0", "in use 2", "waiters 0", "max 2"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("[ERR] expected %q in %q", want, err)
+		}
+	}
+	t.Log("[SUCC]", err)
+}
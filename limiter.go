@@ -0,0 +1,56 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// Limiter bounds the number of concurrent factory calls. Pools sharing a
+// Limiter through FactoryLimiter together create no more than its size of
+// objects at a time, e.g. to spare a backend they all connect to.
+type Limiter struct {
+	slots chan struct{}
+}
+
+func NewLimiter(size int) (*Limiter, error) {
+	if size <= 0 {
+		return nil, ErrInvalidConfig
+	}
+	return &Limiter{slots: make(chan struct{}, size)}, nil
+}
+
+// wait takes a slot, blocking until one is free or ctx is done.
+func (l *Limiter) wait(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *Limiter) done() {
+	<-l.slots
+}
+
+// spaceCreate waits until minCreateInterval has passed since the last factory
+// call, or until ctx is done. Waiting is in real time, whatever the Clock.
+func (p *GenericPool) spaceCreate(ctx context.Context) error {
+	select {
+	case p.createTurn <- struct{}{}:
+		defer func() { <-p.createTurn }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if wait := p.minCreateInterval - time.Since(p.lastCreate); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	p.lastCreate = time.Now()
+	return nil
+}
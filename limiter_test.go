@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter_SharedAcrossPools(t *testing.T) {
+	limiter, err := NewLimiter(2)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var running, peak int32
+	slowFactory := func() (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return factory()
+	}
+	var pools []*GenericPool
+	for i := 0; i < 2; i++ {
+		pool, err := NewGenericPool(&PoolConfig{
+			Max:            10,
+			FactoryFunc:    slowFactory,
+			CloseFunc:      closer,
+			FactoryLimiter: limiter,
+		})
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		pools = append(pools, pool)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(pool *GenericPool) {
+			defer wg.Done()
+			if _, err := pool.Acquire(); err != nil {
+				t.Error("[ERR]", err)
+			}
+		}(pools[i%2])
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&peak); n > 2 {
+		t.Fatalf("[ERR] expected at most 2 concurrent factory calls, got %d", n)
+	}
+	for _, pool := range pools {
+		if stats := pool.Stats(); stats.InUse != 10 {
+			t.Fatalf("[ERR] expected 10 objects in use, got %d", stats.InUse)
+		}
+	}
+	t.Log("[SUCC]", atomic.LoadInt32(&peak))
+}
+
+func TestGenericPool_MinCreateInterval(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	var mu sync.Mutex
+	var starts []time.Time
+	pool, err := NewGenericPool(&PoolConfig{
+		Max: 5,
+		FactoryFunc: func() (interface{}, error) {
+			mu.Lock()
+			starts = append(starts, time.Now())
+			mu.Unlock()
+			return factory()
+		},
+		CloseFunc:         closer,
+		MinCreateInterval: interval,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pool.Acquire(); err != nil {
+				t.Error("[ERR]", err)
+			}
+		}()
+	}
+	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(starts) != 5 {
+		t.Fatalf("[ERR] expected 5 objects created, got %d", len(starts))
+	}
+	// the factory is called right after the wait, allow for scheduling
+	const slack = 2 * time.Millisecond
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < interval-slack {
+			t.Fatalf("[ERR] expected creations %v apart, got %v", interval, gap)
+		}
+	}
+	t.Log("[SUCC]", starts[len(starts)-1].Sub(starts[0]))
+}
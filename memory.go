@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"runtime"
+	"time"
+)
+
+// memoryMonitor periodically checks the heap in use against memoryThreshold,
+// until the pool is shut down.
+func (p *GenericPool) memoryMonitor() {
+	ticker := time.NewTicker(p.memoryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			var ms runtime.MemStats
+			p.readMemStats(&ms)
+			if ms.HeapInuse <= p.memoryThreshold {
+				continue
+			}
+			if closed := p.shrink(); closed > 0 {
+				p.logger.Printf("[POOL][WARN] heap in use %d exceeds %d, closed %d idle objects.", ms.HeapInuse, p.memoryThreshold, closed)
+			}
+		}
+	}
+}
+
+// shrink closes idle objects until only minCap objects are left, and returns
+// how many it closed. Objects idle for less than minIdleBeforeEvict are
+// spared.
+func (p *GenericPool) shrink() int {
+	p.Lock()
+	defer p.Unlock()
+	closed := 0
+	for _, poolObj := range p.drainIdle() {
+		if p.curNum > p.minCap && p.evictable(poolObj) {
+			p.discard(poolObj)
+			closed++
+		} else if !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+	}
+	return closed
+}
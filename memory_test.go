@@ -0,0 +1,108 @@
+package pool
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newGenericPoolReading is NewGenericPool with the pool reading memory stats
+// by read, set before its memory monitor starts.
+func newGenericPoolReading(config *PoolConfig, read func(*runtime.MemStats)) (*GenericPool, error) {
+	p, err := newGenericPool(config)
+	if err != nil {
+		return nil, err
+	}
+	p.readMemStats = read
+	return p, p.init()
+}
+
+func TestGenericPool_MemoryPressure(t *testing.T) {
+	var heapInuse uint64
+	pool, err := newGenericPoolReading(&PoolConfig{
+		Min:                     1,
+		Max:                     4,
+		FactoryFunc:             factory,
+		CloseFunc:               closer,
+		MemoryPressureThreshold: 1 << 20,
+		MemoryCheckInterval:     5 * time.Millisecond,
+	}, func(ms *runtime.MemStats) {
+		ms.HeapInuse = atomic.LoadUint64(&heapInuse)
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	var objs []PoolObject
+	for i := 0; i < 4; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		objs = append(objs, v)
+	}
+	for _, v := range objs {
+		pool.Release(v)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := pool.Len(); n != 4 {
+		t.Fatalf("[ERR] expected no shrinking below threshold, len %d", n)
+	}
+
+	atomic.StoreUint64(&heapInuse, 2<<20)
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Total != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("[ERR] expected pool to shrink to Min, got %+v", pool.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Log("[SUCC]", pool.Len())
+}
+
+func TestGenericPool_MinIdleBeforeEvict(t *testing.T) {
+	var created int32
+	pool, err := newGenericPoolReading(&PoolConfig{
+		Min: 0,
+		Max: 2,
+		FactoryFunc: func() (interface{}, error) {
+			atomic.AddInt32(&created, 1)
+			return factory()
+		},
+		CloseFunc:               closer,
+		MemoryPressureThreshold: 1 << 20,
+		MemoryCheckInterval:     2 * time.Millisecond,
+		MinIdleBeforeEvict:      time.Second,
+	}, func(ms *runtime.MemStats) {
+		// always under pressure
+		ms.HeapInuse = 2 << 20
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	// objects idle only briefly between uses are not churned
+	for i := 0; i < 20; i++ {
+		v1, _ := pool.Acquire()
+		v2, _ := pool.Acquire()
+		time.Sleep(time.Millisecond)
+		pool.Release(v1)
+		pool.Release(v2)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&created); n != 2 {
+		t.Fatalf("[ERR] expected the 2 objects to be reused, created %d", n)
+	}
+
+	// once left idle, they are closed
+	deadline := time.Now().Add(3 * time.Second)
+	for pool.Stats().Total != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("[ERR] expected idle objects to be closed, got %+v", pool.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,60 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// AcquireFunc acquires an object, waiting until ctx is done.
+type AcquireFunc func(ctx context.Context) (PoolObject, error)
+
+// Middleware wraps acquisition for cross-cutting concerns such as logging,
+// metrics or retries. It calls next to acquire from the pool.
+type Middleware func(next AcquireFunc) AcquireFunc
+
+// Use adds middlewares wrapping Acquire and Stream. The first middleware
+// added is the outermost, so it sees every acquire first.
+func (p *GenericPool) Use(mw ...Middleware) {
+	p.Lock()
+	defer p.Unlock()
+	p.middlewares = append(p.middlewares, mw...)
+	chain := AcquireFunc(p.acquire)
+	for i := len(p.middlewares) - 1; i >= 0; i-- {
+		chain = p.middlewares[i](chain)
+	}
+	p.chain.Store(chain)
+}
+
+// acquireFunc returns acquire wrapped by the middlewares
+func (p *GenericPool) acquireFunc() AcquireFunc {
+	if chain, ok := p.chain.Load().(AcquireFunc); ok {
+		return chain
+	}
+	return p.acquire
+}
+
+// LoggingMiddleware logs every failed acquire to logger.
+func LoggingMiddleware(logger Logger) Middleware {
+	return func(next AcquireFunc) AcquireFunc {
+		return func(ctx context.Context) (PoolObject, error) {
+			poolObj, err := next(ctx)
+			if err != nil {
+				logger.Printf("[POOL][WARN] acquire failed: %v", err)
+			}
+			return poolObj, err
+		}
+	}
+}
+
+// TimingMiddleware calls observe with how long every acquire took, and its
+// error.
+func TimingMiddleware(observe func(time.Duration, error)) Middleware {
+	return func(next AcquireFunc) AcquireFunc {
+		return func(ctx context.Context) (PoolObject, error) {
+			start := time.Now()
+			poolObj, err := next(ctx)
+			observe(time.Since(start), err)
+			return poolObj, err
+		}
+	}
+}
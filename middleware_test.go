@@ -0,0 +1,55 @@
+package pool
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenericPool_Use(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	var calls []string
+	counting := func(name string) Middleware {
+		return func(next AcquireFunc) AcquireFunc {
+			return func(ctx context.Context) (PoolObject, error) {
+				calls = append(calls, name+" before")
+				poolObj, err := next(ctx)
+				calls = append(calls, name+" after")
+				return poolObj, err
+			}
+		}
+	}
+	var timed int
+	pool.Use(counting("outer"), counting("inner"))
+	pool.Use(TimingMiddleware(func(time.Duration, error) { timed++ }))
+
+	for i := 0; i < 2; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		defer pool.Release(v)
+	}
+	want := []string{
+		"outer before", "inner before", "inner after", "outer after",
+		"outer before", "inner before", "inner after", "outer after",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("[ERR] expected middlewares called in order, got %v", calls)
+	}
+	if timed != 2 {
+		t.Fatalf("[ERR] expected 2 timed acquires, got %d", timed)
+	}
+	t.Log("[SUCC]", calls)
+}
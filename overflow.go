@@ -0,0 +1,60 @@
+package pool
+
+import "time"
+
+// overflowPool returns the overflow pool, creating it on first use, or nil if
+// there is none. Must be called with the lock held.
+func (p *GenericPool) overflowPool() *GenericPool {
+	if p.overflowMax <= 0 || p.closed {
+		return nil
+	}
+	if p.overflow != nil {
+		return p.overflow
+	}
+	cfg := p.settings()
+	o, err := newGenericPool(&PoolConfig{
+		Max:                 p.overflowMax,
+		FactoryFunc:         cfg.factoryFunc,
+		TaggedFactoryFunc:   cfg.taggedFactoryFunc,
+		FactoryFuncStateful: cfg.statefulFactoryFunc,
+		FactoryFuncCtx:      cfg.contextFactoryFunc,
+		FactoryLimiter:      p.limiter,
+		Clock:               p.clock,
+		MinIdleBeforeEvict:  p.overflowIdleTimeout,
+		Logger:              p.logger,
+		PanicHandler:        p.panicHandler,
+	})
+	if err != nil {
+		return nil
+	}
+	// objects are created and closed as by this pool
+	o.cfg.Store(cfg)
+	o.parent = p
+	close(o.ready)
+	go o.reap(p.overflowIdleTimeout / 2)
+	p.overflow = o
+	return o
+}
+
+// isOverflow reports whether the object was lent by the overflow pool
+func (p *GenericPool) isOverflow(poolObj PoolObject) bool {
+	return poolObj.pool != nil && poolObj.pool != p && poolObj.pool.parent == p
+}
+
+// reap closes objects idle for minIdleBeforeEvict down to minCap, every
+// interval until the pool is shut down.
+func (p *GenericPool) reap(interval time.Duration) {
+	if interval < minMonitorInterval {
+		interval = minMonitorInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.shrink()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package pool
+
+import "sync/atomic"
+
+// lenders returns the pools to borrow from when this one is at Max, the
+// overflow pool and then the Parent. Must be called with the lock held.
+func (p *GenericPool) lenders() []*GenericPool {
+	var lenders []*GenericPool
+	if overflow := p.overflowPool(); overflow != nil {
+		lenders = append(lenders, overflow)
+	}
+	if p.backing != nil {
+		lenders = append(lenders, p.backing)
+	}
+	return lenders
+}
+
+// isBorrowed reports whether the object was lent by the Parent pool
+func (p *GenericPool) isBorrowed(poolObj PoolObject) bool {
+	return p.backing != nil && poolObj.pool == p.backing
+}
+
+// adopt takes over an object lent by the Parent pool as if this pool had
+// created it, and reports false if it has no room for it. The object stays
+// checked out, now from this pool.
+func (p *GenericPool) adopt(poolObj PoolObject) (PoolObject, bool) {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed || p.draining || p.curNum >= p.maxCap {
+		return poolObj, false
+	}
+	parent := p.backing
+	parent.Lock()
+	defer parent.Unlock()
+	c, ok := parent.inUse[poolObj.id]
+	if !ok || parent.closed {
+		// misuse for the parent to report
+		return poolObj, false
+	}
+	parent.checkin(poolObj.id)
+	parent.freeSlot()
+	poolObj.pool = p
+	poolObj.id = p.ids.next()
+	poolObj.gen = atomic.LoadUint64(&p.generation)
+	p.curNum++
+	p.inUse[poolObj.id] = &checkout{poolObj: poolObj, since: c.since, bytes: c.bytes}
+	atomic.AddInt64(&p.inUseCount, 1)
+	p.checkMin()
+	return poolObj, true
+}
@@ -0,0 +1,74 @@
+package pool
+
+import "testing"
+
+func TestGenericPool_Parent(t *testing.T) {
+	parent, err := NewGenericPool(&PoolConfig{
+		Min:         0,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	child, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		Parent:      parent,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	// the child is exhausted, so it borrows from the parent
+	v1, _ := child.Acquire()
+	v2, err := child.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if v1.pool != child || v2.pool != parent {
+		t.Fatal("[ERR] expected the second object borrowed from the parent")
+	}
+	if stats := parent.Stats(); stats.InUse != 1 {
+		t.Fatalf("[ERR] expected 1 object lent by the parent, got %+v", stats)
+	}
+
+	// the child is full, so the borrowed object goes back to the parent
+	if err := child.Release(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := child.Release(v2); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := parent.Stats(); stats.Idle != 1 || stats.InUse != 0 {
+		t.Fatalf("[ERR] expected the object returned to the parent, got %+v", stats)
+	}
+
+	// with room in the child, it keeps the borrowed object
+	v1, _ = child.Acquire()
+	v2, _ = child.Acquire()
+	if err := child.Close(v1); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := child.Release(v2); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := child.Stats(); stats.Idle != 1 || stats.Total != 1 {
+		t.Fatalf("[ERR] expected the child to keep the object, got %+v", stats)
+	}
+	if stats := parent.Stats(); stats.Total != 0 {
+		t.Fatalf("[ERR] expected the parent to let go of the object, got %+v", stats)
+	}
+	if v, _ := child.Acquire(); v.Object != v2.Object {
+		t.Fatalf("[ERR] expected the adopted object, got %v", v.Object)
+	}
+	for _, pool := range []*GenericPool{parent, child} {
+		if err := pool.Verify(); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	t.Log("[SUCC]", child.Stats(), parent.Stats())
+}
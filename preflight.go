@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PreflightCheck acquires every idle object once and checks it with
+// ValidateFunc, as a readiness gate for services which must not start with
+// bad connections. Objects failing the check are closed, and reported in an
+// ErrObjectInvalid error. Objects acquired meanwhile are skipped.
+func (p *GenericPool) PreflightCheck() error {
+	if p.validateFunc == nil {
+		return nil
+	}
+	p.Lock()
+	if p.closed {
+		p.Unlock()
+		return ErrPoolClosed
+	}
+	idle := p.idleObjects()
+	p.Unlock()
+	var errs []error
+	for _, idleObj := range idle {
+		poolObj, ok, err := p.AcquireByID(idleObj.id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		err = p.protect("ValidateFunc", func() error {
+			return p.validateFunc(poolObj.Object)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("object %d: %w", poolObj.ID(), err))
+			p.Close(poolObj)
+			continue
+		}
+		p.Release(poolObj)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %d of %d objects: %w", ErrObjectInvalid, len(errs), len(idle), errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGenericPool_PreflightCheck(t *testing.T) {
+	var bad interface{}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         3,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		ValidateFunc: func(obj interface{}) error {
+			if obj == bad {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.PreflightCheck(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	var badID uint64
+	pool.ForEachIdle(func(poolObj PoolObject) {
+		if bad == nil {
+			bad, badID = poolObj.Object, poolObj.ID()
+		}
+	})
+	err = pool.PreflightCheck()
+	if !errors.Is(err, ErrObjectInvalid) {
+		t.Fatalf("[ERR] expected ErrObjectInvalid, got %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, fmt.Sprintf("object %d: connection refused", badID)) {
+		t.Fatalf("[ERR] expected the bad object named in %q", msg)
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.Total != 2 {
+		t.Fatalf("[ERR] expected the bad object closed, got %+v", stats)
+	}
+	t.Log("[SUCC]", err)
+}
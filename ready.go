@@ -0,0 +1,63 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WaitReady blocks until the pool has been filled up to Min, which only takes
+// time with LazyInit or SyncInit, or until ctx is done. It returns the error
+// that stopped a lazy pool from filling, if any.
+func (p *GenericPool) WaitReady(ctx context.Context) error {
+	select {
+	case <-p.ready:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	p.Lock()
+	defer p.Unlock()
+	return p.readyErr
+}
+
+// fillLazily creates objects up to warmCap for a LazyInit pool, or the ones
+// SyncInit left, alongside acquires which may already be taking them. Unlike
+// init, it keeps whatever it managed to create when the factory keeps
+// failing.
+func (p *GenericPool) fillLazily() {
+	defer close(p.ready)
+	var lastErr error
+	attempts := 0
+	for attempts < p.warmCap*initAttemptFactor {
+		p.Lock()
+		if p.closed || p.curNum >= p.warmCap {
+			p.Unlock()
+			return
+		}
+		p.reserve()
+		p.Unlock()
+		attempts++
+		poolObj, err := p.createReserved(p.bgCtx)
+		if err != nil {
+			lastErr = err
+			time.Sleep(initRetryDelay)
+			continue
+		}
+		p.Lock()
+		if p.closed {
+			p.closeAs(poolObj, EventClosed)
+			p.curNum--
+		} else if !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+		p.Unlock()
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.closed || p.curNum >= p.warmCap {
+		return
+	}
+	p.readyErr = fmt.Errorf("%w: created %d of %d objects in %d attempts, last error: %w",
+		ErrFactoryFunc, p.curNum, p.warmCap, attempts, lastErr)
+	p.logger.Printf("[POOL][ERROR] lazy init failed: %v", p.readyErr)
+}
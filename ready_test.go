@@ -0,0 +1,92 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGenericPool_WaitReady(t *testing.T) {
+	start := time.Now()
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 2,
+		Max: 3,
+		FactoryFunc: func() (interface{}, error) {
+			time.Sleep(100 * time.Millisecond)
+			return factory()
+		},
+		CloseFunc: closer,
+		LazyInit:  true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("[ERR] expected lazy pool to be returned right away, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := pool.WaitReady(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("[ERR] expected WaitReady to time out, got %v", err)
+	}
+	if err := pool.WaitReady(context.Background()); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := pool.Len(); n != 2 {
+		t.Fatalf("[ERR] expected pool filled to Min, len %d", n)
+	}
+
+	// a lazy pool whose factory fails still comes up, and reports why
+	failing, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: func() (interface{}, error) { return nil, errors.New("down") },
+		CloseFunc:   closer,
+		LazyInit:    true,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer failing.Shutdown()
+	if err := failing.WaitReady(context.Background()); !errors.Is(err, ErrFactoryFunc) {
+		t.Fatalf("[ERR] expected ErrFactoryFunc, got %v", err)
+	}
+	t.Log("[SUCC]", pool.Len(), failing.Len())
+}
+
+func TestGenericPool_SyncInit(t *testing.T) {
+	var calls int32
+	warm := make(chan struct{})
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 5,
+		Max: 5,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) > 1 {
+				// background creation waits until the constructor returned
+				<-warm
+			}
+			return factory()
+		},
+		CloseFunc: closer,
+		SyncInit:  1,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	if n := pool.Len(); n != 1 {
+		t.Fatalf("[ERR] expected 1 object created by the constructor, len %d", n)
+	}
+	close(warm)
+	if err := pool.WaitReady(context.Background()); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if n := pool.Len(); n != 5 {
+		t.Fatalf("[ERR] expected pool filled to Min in the background, len %d", n)
+	}
+	t.Log("[SUCC]", atomic.LoadInt32(&calls))
+}
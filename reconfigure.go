@@ -0,0 +1,169 @@
+package pool
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// settings are the parts of the config which Reconfigure replaces. They are
+// read without the lock, so they are never modified, only swapped as a whole.
+type settings struct {
+	maxLifeTime         time.Duration
+	tagLifeTimes        map[string]time.Duration
+	refresh             bool // reset CreateTime on release
+	factoryFunc         FactoryFunc
+	taggedFactoryFunc   TaggedFactoryFunc
+	statefulFactoryFunc StatefulFactoryFunc
+	contextFactoryFunc  ContextFactoryFunc
+	fallbackFactoryFunc FactoryFunc
+	closeFunc           CloseFunc
+	recycleFunc         RecycleFunc
+	resetFunc           ResetFunc
+	postCreateFunc      func(interface{}) error
+}
+
+func newSettings(config *PoolConfig) *settings {
+	cfg := &settings{
+		maxLifeTime:         config.LiftTime,
+		tagLifeTimes:        make(map[string]time.Duration, len(config.TagLifeTimes)),
+		refresh:             config.RefreshOnRelease,
+		factoryFunc:         config.FactoryFunc,
+		taggedFactoryFunc:   config.TaggedFactoryFunc,
+		statefulFactoryFunc: config.FactoryFuncStateful,
+		contextFactoryFunc:  config.FactoryFuncCtx,
+		fallbackFactoryFunc: config.FallbackFactoryFunc,
+		closeFunc:           config.CloseFunc,
+		recycleFunc:         config.RecycleFunc,
+		resetFunc:           config.ResetFunc,
+		postCreateFunc:      config.PostCreateFunc,
+	}
+	if cfg.resetFunc == nil && config.AutoReset {
+		cfg.resetFunc = resetObject
+	}
+	for tag, lifeTime := range config.TagLifeTimes {
+		cfg.tagLifeTimes[tag] = lifeTime
+	}
+	return cfg
+}
+
+// lifeTime returns the LiftTime of objects with tag
+func (cfg *settings) lifeTime(tag string) time.Duration {
+	if lifeTime, ok := cfg.tagLifeTimes[tag]; ok {
+		return lifeTime
+	}
+	return cfg.maxLifeTime
+}
+
+// current settings of the pool
+func (p *GenericPool) settings() *settings {
+	return p.cfg.Load().(*settings)
+}
+
+// Reconfigure applies a new config to the running pool. Min, Max, LiftTime,
+// TagLifeTimes, RefreshOnRelease and the factory, fallback factory, close,
+// recycle, reset and post create functions are replaced, the rest of the
+// config is ignored. The pool is resized to the new Max, and filled up to the
+// new Min. Objects created under the old config are replaced, idle ones when
+// they would be acquired, and ones in use when they are released.
+//
+// A factory creating objects of another type than the pool holds is rejected
+// with ErrTypeMismatch, and so is a config failing ValidateConfig, or
+// switching LessFunc or OrderLRU on or off, with ErrInvalidConfig. Either way
+// the pool keeps its old config.
+func (p *GenericPool) Reconfigure(config *PoolConfig) error {
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+	if (config.idleLess() != nil) != (p.sorted != nil) {
+		return ErrInvalidConfig
+	}
+	cfg := newSettings(config)
+
+	// try the new factory on an object, which is kept if it fits
+	p.Lock()
+	want := p.sampleType()
+	p.Unlock()
+	var probe *PoolObject
+	if want != nil {
+		poolObj, err := p.createWith(context.Background(), cfg)
+		if err != nil {
+			return err
+		}
+		if reflect.TypeOf(poolObj.Object) != want {
+			p.closeWith(cfg, poolObj.Object)
+			p.emit(EventClosed, poolObj)
+			return ErrTypeMismatch
+		}
+		probe = &poolObj
+	}
+
+	p.Lock()
+	if p.closed {
+		p.Unlock()
+		if probe != nil {
+			p.closeWith(cfg, probe.Object)
+			p.emit(EventClosed, *probe)
+		}
+		return ErrPoolClosed
+	}
+	p.cfg.Store(cfg)
+	atomic.AddUint64(&p.generation, 1)
+	p.minCap = config.Min
+	if config.Max != p.maxCap {
+		p.resize(config.Max)
+	}
+	if probe != nil {
+		probe.gen = atomic.LoadUint64(&p.generation)
+		if p.curNum < p.maxCap && p.putIdle(*probe) {
+			p.curNum++
+		} else {
+			p.closeAs(*probe, EventClosed)
+		}
+	}
+	n := p.minCap - p.curNum
+	for i := 0; i < n; i++ {
+		p.reserve()
+	}
+	p.checkMin()
+	p.Unlock()
+	return p.fillReserved(n)
+}
+
+// sampleType returns the type of the objects in the pool, or nil if there are
+// none to tell. Must be called with the lock held.
+func (p *GenericPool) sampleType() reflect.Type {
+	if p.objType != nil {
+		return p.objType
+	}
+	for _, c := range p.inUse {
+		return reflect.TypeOf(c.poolObj.Object)
+	}
+	if idle := p.idleObjects(); len(idle) > 0 {
+		return reflect.TypeOf(idle[0].Object)
+	}
+	return nil
+}
+
+// SetFactory replaces the factory, like Reconfigure with only FactoryFunc
+// changed. Objects created by the old factory are replaced, idle ones when
+// they would be acquired, and ones in use when they are released.
+func (p *GenericPool) SetFactory(factory FactoryFunc) error {
+	if factory == nil {
+		return ErrInvalidConfig
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	cfg := *p.settings()
+	cfg.factoryFunc = factory
+	cfg.taggedFactoryFunc = nil
+	cfg.statefulFactoryFunc = nil
+	cfg.contextFactoryFunc = nil
+	p.cfg.Store(&cfg)
+	atomic.AddUint64(&p.generation, 1)
+	return nil
+}
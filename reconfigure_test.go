@@ -0,0 +1,159 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGenericPool_Reconfigure(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	old, _ := pool.Acquire()
+	pool.Release(old)
+	err = pool.Reconfigure(&PoolConfig{
+		Min:         2,
+		Max:         4,
+		LiftTime:    30 * time.Minute,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Max != 4 || stats.Total != 2 {
+		t.Fatalf("[ERR] expected pool resized and filled to Min, got %+v", stats)
+	}
+
+	// the new Max takes effect
+	var objs []PoolObject
+	for i := 0; i < 4; i++ {
+		v, created, err := pool.AcquireOrCreate()
+		if err != nil {
+			t.Fatalf("[ERR] acquire %d: %v", i, err)
+		}
+		if i == 0 && created {
+			// the object the new factory was tried on is kept
+			t.Fatal("[ERR] expected idle object for the first acquire")
+		}
+		if v.ID() == old.ID() {
+			t.Fatal("[ERR] expected the object of the old config replaced")
+		}
+		objs = append(objs, v)
+	}
+	if _, _, err := pool.AcquireOrCreate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("[ERR] expected ErrPoolExhausted, got %v", err)
+	}
+
+	// and so does the new lifetime
+	pool.Release(objs[0])
+	setObjectCreateTime(&objs[0], aged(30*time.Minute))
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if v.ID() == objs[0].ID() {
+		t.Fatal("[ERR] expected expired object to be replaced")
+	}
+}
+
+func TestGenericPool_ReconfigureTypeMismatch(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	err = pool.Reconfigure(&PoolConfig{
+		Min:         1,
+		Max:         3,
+		FactoryFunc: func() (interface{}, error) { return "not an int", nil },
+		CloseFunc:   closer,
+	})
+	if err != ErrTypeMismatch {
+		t.Fatalf("[ERR] expected ErrTypeMismatch, got %v", err)
+	}
+	if stats := pool.Stats(); stats.Max != 2 || stats.Total != 1 {
+		t.Fatalf("[ERR] expected old config to be kept, got %+v", stats)
+	}
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if _, ok := v.Object.(int); !ok {
+		t.Fatalf("[ERR] expected old factory to be kept, got %T", v.Object)
+	}
+}
+
+func TestGenericPool_ReconfigureInvalid(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	// an empty pool has no object to try the factory on
+	for _, config := range []*PoolConfig{
+		nil,
+		{Max: 2},
+		{Min: -1, Max: 2, FactoryFunc: factory},
+		{Max: 2, FactoryFunc: factory, Ordering: OrderLRU + 1},
+	} {
+		if err := pool.Reconfigure(config); !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("[ERR] expected ErrInvalidConfig for %+v, got %v", config, err)
+		}
+	}
+	v, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if _, ok := v.Object.(int); !ok {
+		t.Fatalf("[ERR] expected old factory to be kept, got %T", v.Object)
+	}
+}
+
+func TestGenericPool_SetFactory(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         3,
+		FactoryFunc: func() (interface{}, error) { return "old", nil },
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	inUse, _ := pool.Acquire()
+	if err := pool.SetFactory(func() (interface{}, error) { return "new", nil }); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// the idle old object is replaced on acquire, the one in use on release
+	v, _ := pool.Acquire()
+	if v.Object != "new" {
+		t.Fatalf("[ERR] expected a new generation object, got %v", v.Object)
+	}
+	pool.Release(inUse)
+	pool.Release(v)
+	if stats := pool.Stats(); stats.Total != 1 {
+		t.Fatalf("[ERR] expected only the new object left, got %+v", stats)
+	}
+	// new generation objects persist
+	w, _ := pool.Acquire()
+	if w.ID() != v.ID() {
+		t.Fatalf("[ERR] expected object %d reused, got %d", v.ID(), w.ID())
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
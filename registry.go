@@ -0,0 +1,88 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultRegistryLimit = 1024 // pools DefaultRegistry takes at most
+
+// DefaultRegistry holds the pools created with RegisterWithDefault.
+var DefaultRegistry = NewRegistry(defaultRegistryLimit)
+
+// Registry keeps track of live pools, so apps with many of them can read
+// their stats together. It holds at most limit pools.
+type Registry struct {
+	sync.Mutex
+	pools map[*GenericPool]struct{}
+	limit int
+}
+
+func NewRegistry(limit int) *Registry {
+	return &Registry{
+		pools: make(map[*GenericPool]struct{}),
+		limit: limit,
+	}
+}
+
+// Register adds a pool, or returns ErrRegistryFull if the registry holds limit
+// pools already. Registering a pool twice is a no-op.
+func (r *Registry) Register(p *GenericPool) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.pools[p]; ok {
+		return nil
+	}
+	if len(r.pools) >= r.limit {
+		return ErrRegistryFull
+	}
+	r.pools[p] = struct{}{}
+	return nil
+}
+
+// Deregister removes a pool, pools are removed on shutdown as well.
+func (r *Registry) Deregister(p *GenericPool) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.pools, p)
+}
+
+// number of registered pools
+func (r *Registry) Len() int {
+	r.Lock()
+	defer r.Unlock()
+	return len(r.pools)
+}
+
+// AggregateStats sums the Stats of all registered pools. AvgCreateTime is
+// the average over the objects of all pools.
+func (r *Registry) AggregateStats() PoolStats {
+	// read the pools without the registry lock, shutdown deregisters with
+	// the pool lock held
+	r.Lock()
+	pools := make([]*GenericPool, 0, len(r.pools))
+	for p := range r.pools {
+		pools = append(pools, p)
+	}
+	r.Unlock()
+
+	var total PoolStats
+	var created int64
+	for _, p := range pools {
+		stats := p.Stats()
+		total.Idle += stats.Idle
+		total.InUse += stats.InUse
+		total.Total += stats.Total
+		total.Max += stats.Max
+		total.WaiterCount += stats.WaiterCount
+		total.TotalCreateTime += stats.TotalCreateTime
+		total.AcquireFailureCount += stats.AcquireFailureCount
+		total.DroppedEvents += stats.DroppedEvents
+		created += atomic.LoadInt64(&p.createCount)
+	}
+	if created > 0 {
+		total.AvgCreateTime = total.TotalCreateTime / time.Duration(created)
+	}
+	return total
+}
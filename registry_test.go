@@ -0,0 +1,60 @@
+package pool
+
+import "testing"
+
+func TestRegistry_AggregateStats(t *testing.T) {
+	var pools []*GenericPool
+	for i := 0; i < 3; i++ {
+		pool, err := NewGenericPool(&PoolConfig{
+			Min:                 2,
+			Max:                 4,
+			FactoryFunc:         factory,
+			CloseFunc:           closer,
+			RegisterWithDefault: true,
+		})
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		pools = append(pools, pool)
+	}
+	for _, pool := range pools[:2] {
+		if _, err := pool.Acquire(); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+	}
+	stats := DefaultRegistry.AggregateStats()
+	if stats.Idle != 4 || stats.InUse != 2 || stats.Total != 6 || stats.Max != 12 {
+		t.Fatalf("[ERR] unexpected aggregate stats %+v", stats)
+	}
+
+	// shut down pools leave the registry
+	pools[2].Shutdown()
+	if n := DefaultRegistry.Len(); n != 2 {
+		t.Fatalf("[ERR] expected 2 registered pools, got %d", n)
+	}
+	if stats := DefaultRegistry.AggregateStats(); stats.Total != 4 {
+		t.Fatalf("[ERR] expected shut down pool left out, got %+v", stats)
+	}
+	for _, pool := range pools[:2] {
+		pool.Kill()
+	}
+	if n := DefaultRegistry.Len(); n != 0 {
+		t.Fatalf("[ERR] expected empty registry, got %d", n)
+	}
+	t.Log("[SUCC]", stats)
+}
+
+func TestRegistry_Full(t *testing.T) {
+	registry := NewRegistry(1)
+	for i := 0; i < 2; i++ {
+		pool, err := NewGenericPool(&PoolConfig{Max: 1, FactoryFunc: factory, CloseFunc: closer})
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		defer pool.Shutdown()
+		err = registry.Register(pool)
+		if i == 1 && err != ErrRegistryFull {
+			t.Fatalf("[ERR] expected ErrRegistryFull, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package pool
+
+import "time"
+
+// replaceMonitor swaps idle objects within replaceBefore of the end of their
+// lifetime for new ones, so acquires never find them just expired.
+func (p *GenericPool) replaceMonitor() {
+	interval := p.replaceBefore / 2
+	if interval < minMonitorInterval {
+		interval = minMonitorInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.replaceExpiring()
+		}
+	}
+}
+
+// replaceExpiring replaces the idle objects about to expire. They are taken
+// out of the idle objects and counted as creating meanwhile, and are kept if
+// the factory fails.
+func (p *GenericPool) replaceExpiring() {
+	cfg := p.settings()
+	now := p.now()
+	p.Lock()
+	var expiring []PoolObject
+	for _, poolObj := range p.drainIdle() {
+		lifeTime := cfg.lifeTime(poolObj.Tag)
+		if lifeTime > 0 && poolObj.CreateTime <= now.Add(p.replaceBefore-lifeTime).UnixNano() {
+			expiring = append(expiring, poolObj)
+			p.creating++
+		} else if !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+	}
+	p.Unlock()
+
+	for _, poolObj := range expiring {
+		fresh, err := p.createWith(p.bgCtx, cfg)
+		p.Lock()
+		p.creating--
+		if err == nil {
+			p.closeAs(poolObj, EventExpired)
+			poolObj = fresh
+		}
+		if p.closed {
+			// shut down meanwhile, which only closed the idle objects
+			p.closeAs(poolObj, EventClosed)
+			p.curNum--
+		} else if !p.putIdle(poolObj) {
+			p.discard(poolObj)
+		}
+		p.Unlock()
+	}
+}
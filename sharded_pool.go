@@ -0,0 +1,135 @@
+package pool
+
+import "sync/atomic"
+
+// ShardedPool spreads acquires over several alike pools, to cut contention on
+// a single pool lock.
+type ShardedPool struct {
+	shards []*GenericPool
+	next   uint64 // shard the next acquire starts at, accessed atomically
+}
+
+func NewShardedPool(shards ...*GenericPool) (*ShardedPool, error) {
+	if len(shards) == 0 {
+		return nil, ErrInvalidConfig
+	}
+	seen := make(map[*GenericPool]bool, len(shards))
+	for _, p := range shards {
+		if p == nil || seen[p] {
+			return nil, ErrInvalidConfig
+		}
+		seen[p] = true
+	}
+	return &ShardedPool{shards: shards}, nil
+}
+
+// acquire object from the shards in turn, taking an idle one or creating one
+// wherever possible. If all shards are exhausted, it waits on the first shard
+// tried.
+func (s *ShardedPool) Acquire() (PoolObject, error) {
+	start := int(atomic.AddUint64(&s.next, 1) % uint64(len(s.shards)))
+	for i := range s.shards {
+		poolObj, _, err := s.shards[(start+i)%len(s.shards)].AcquireOrCreate()
+		if err == nil {
+			return poolObj, nil
+		}
+	}
+	return s.shards[start].Acquire()
+}
+
+// release object into the shard it was acquired from
+func (s *ShardedPool) Release(poolObj PoolObject) error {
+	return s.origin(poolObj).Release(poolObj)
+}
+
+// close or delete object
+func (s *ShardedPool) Close(poolObj PoolObject) error {
+	return s.origin(poolObj).Close(poolObj)
+}
+
+func (s *ShardedPool) origin(poolObj PoolObject) *GenericPool {
+	for _, p := range s.shards {
+		if poolObj.pool == p {
+			return p
+		}
+	}
+	// not ours, let the first shard report it
+	return s.shards[0]
+}
+
+// shutdown all shards, returning the first error
+func (s *ShardedPool) Shutdown() error {
+	var err error
+	for _, p := range s.shards {
+		if e := p.Shutdown(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Rebalance moves idle objects from the shards with the most to the shards
+// with the fewest, until their idle counts differ by at most one, as far as
+// the shards' Max allows. Moved objects keep their age, tag and metadata, but
+// get a new ID in their new shard.
+func (s *ShardedPool) Rebalance() {
+	for {
+		src, dst := s.extremes()
+		if src == dst || !s.move(src, dst) {
+			return
+		}
+	}
+}
+
+// extremes returns the shards with the most and the fewest idle objects, of
+// those with room for one more.
+func (s *ShardedPool) extremes() (src, dst int) {
+	most, fewest := -1, -1
+	for i, p := range s.shards {
+		p.Lock()
+		idle, room := p.idleLen(), p.curNum < p.maxCap
+		p.Unlock()
+		if idle > most {
+			src, most = i, idle
+		}
+		if room && (fewest < 0 || idle < fewest) {
+			dst, fewest = i, idle
+		}
+	}
+	if fewest < 0 {
+		// no shard can take an object
+		return src, src
+	}
+	return src, dst
+}
+
+// move hands one idle object from shard src to shard dst, if that evens them
+// out, and reports whether it did. Both shards are locked, in index order, so
+// the counts can't change in between.
+func (s *ShardedPool) move(src, dst int) bool {
+	from, to := s.shards[src], s.shards[dst]
+	first, second := from, to
+	if dst < src {
+		first, second = to, from
+	}
+	first.Lock()
+	defer first.Unlock()
+	second.Lock()
+	defer second.Unlock()
+	if from.closed || to.closed || from.idleLen()-to.idleLen() < 2 || to.curNum >= to.maxCap {
+		return false
+	}
+	poolObj, ok := from.takeIdle()
+	if !ok {
+		return false
+	}
+	from.freeSlot()
+	poolObj.pool = to
+	poolObj.id = to.ids.next()
+	poolObj.gen = atomic.LoadUint64(&to.generation)
+	to.curNum++
+	if !to.putIdle(poolObj) {
+		to.discard(poolObj)
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestShardedPool_Rebalance(t *testing.T) {
+	var shards []*GenericPool
+	for i := 0; i < 3; i++ {
+		p, err := NewGenericPool(&PoolConfig{
+			Min:         0,
+			Max:         6,
+			FactoryFunc: factory,
+			CloseFunc:   closer,
+		})
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		shards = append(shards, p)
+	}
+	pool, err := NewShardedPool(shards...)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	// all idle objects end up on the first shard
+	objs, err := shards[0].AcquireN(6)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	for _, v := range objs {
+		pool.Release(v)
+	}
+
+	// rebalanced under concurrent use
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				v, err := pool.Acquire()
+				if err != nil {
+					t.Error("[ERR]", err)
+					return
+				}
+				pool.Release(v)
+			}
+		}()
+	}
+	pool.Rebalance()
+	wg.Wait()
+	pool.Rebalance()
+
+	var idle []int
+	for _, p := range shards {
+		if err := p.Verify(); err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		idle = append(idle, p.Len())
+	}
+	for _, n := range idle {
+		if n < 2 || n-idle[0] > 1 || idle[0]-n > 1 {
+			t.Fatalf("[ERR] expected idle objects spread evenly, got %v", idle)
+		}
+	}
+	t.Log("[SUCC]", idle)
+}
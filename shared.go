@@ -0,0 +1,43 @@
+package pool
+
+// object lent to several callers at once by AcquireShared
+type sharedObject struct {
+	poolObj PoolObject
+	refs    int
+}
+
+// AcquireShared acquires an object for read-only use. Concurrent shared
+// holders get the same object, which goes back to the pool when the last of
+// them calls ReleaseShared. Objects acquired by Acquire stay exclusive.
+func (p *GenericPool) AcquireShared() (PoolObject, error) {
+	p.sharedMu.Lock()
+	defer p.sharedMu.Unlock()
+	if p.shared != nil {
+		p.shared.refs++
+		return p.shared.poolObj, nil
+	}
+	poolObj, err := p.Acquire()
+	if err != nil {
+		return poolObj, err
+	}
+	p.shared = &sharedObject{poolObj: poolObj, refs: 1}
+	return poolObj, nil
+}
+
+// ReleaseShared gives up one reference to an object acquired by AcquireShared.
+func (p *GenericPool) ReleaseShared(poolObj PoolObject) error {
+	p.sharedMu.Lock()
+	defer p.sharedMu.Unlock()
+	if p.wrongPool(poolObj) {
+		return p.misuse(ErrWrongPool)
+	}
+	if p.shared == nil || p.shared.poolObj.id != poolObj.id || poolObj.pool != p {
+		return p.misuse(ErrNotInUse)
+	}
+	p.shared.refs--
+	if p.shared.refs > 0 {
+		return nil
+	}
+	p.shared = nil
+	return p.Release(poolObj)
+}
@@ -0,0 +1,57 @@
+package pool
+
+import "time"
+
+// PoolState describes what a pool held, to warm a new pool to the same size
+// after a restart by NewGenericPoolFromState. Objects themselves can't be
+// persisted, only their number, ages and tags.
+type PoolState struct {
+	Total int             // objects created and not yet closed
+	Idle  int             // objects waiting in the pool
+	InUse int             // objects acquired and not yet released
+	Ages  []time.Duration // age of each object, idle ones first
+	Tags  []string        // tag of each object, in the order of Ages
+}
+
+// SnapshotState returns the current state of the pool.
+func (p *GenericPool) SnapshotState() PoolState {
+	p.Lock()
+	defer p.Unlock()
+	idle := p.idleObjects()
+	state := PoolState{
+		Total: p.curNum,
+		Idle:  len(idle),
+		InUse: len(p.inUse),
+	}
+	now := p.now().UnixNano()
+	add := func(poolObj PoolObject) {
+		state.Ages = append(state.Ages, time.Duration(now-poolObj.CreateTime))
+		state.Tags = append(state.Tags, poolObj.Tag)
+	}
+	for _, poolObj := range idle {
+		add(poolObj)
+	}
+	for _, c := range p.inUse {
+		add(c.poolObj)
+	}
+	return state
+}
+
+// NewGenericPoolFromState is like NewGenericPool, but fills the pool up to the
+// Total of a previous state rather than just Min, capped at Max. Later on the
+// pool shrinks and grows as usual, keeping only Min. With LazyInit or
+// SyncInit the pool warms up to Total in the background, and WaitReady waits
+// for that.
+func NewGenericPoolFromState(config *PoolConfig, state PoolState) (*GenericPool, error) {
+	p, err := newGenericPool(config)
+	if err != nil {
+		return nil, err
+	}
+	if state.Total > p.warmCap {
+		p.warmCap = state.Total
+	}
+	if p.warmCap > p.maxCap {
+		p.warmCap = p.maxCap
+	}
+	return p, p.init()
+}
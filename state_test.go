@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewGenericPoolFromState(t *testing.T) {
+	config := &PoolConfig{
+		Min:         1,
+		Max:         5,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	}
+	pool, err := NewGenericPool(config)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	var objs []PoolObject
+	for i := 0; i < 4; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		objs = append(objs, v)
+	}
+	pool.Release(objs[0])
+	state := pool.SnapshotState()
+	if state.Total != 4 || state.Idle != 1 || state.InUse != 3 || len(state.Ages) != 4 || len(state.Tags) != 4 {
+		t.Fatalf("[ERR] unexpected state %+v", state)
+	}
+	pool.Kill()
+
+	// restarted warm at the previous size
+	pool, err = NewGenericPoolFromState(config, state)
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	if stats := pool.Stats(); stats.Idle != 4 || stats.Total != 4 {
+		t.Fatalf("[ERR] expected pool warmed to 4 objects, got %+v", stats)
+	}
+	if pool.minCap != config.Min {
+		t.Fatalf("[ERR] expected Min kept at %d, got %d", config.Min, pool.minCap)
+	}
+	t.Log("[SUCC]", state)
+}
+
+func TestNewGenericPoolFromState_SyncInit(t *testing.T) {
+	config := &PoolConfig{
+		Min:         1,
+		Max:         5,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		SyncInit:    1,
+	}
+	// the rest of the previous size is created in the background
+	pool, err := NewGenericPoolFromState(config, PoolState{Total: 5})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	if err := pool.WaitReady(context.Background()); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Total != 5 {
+		t.Fatalf("[ERR] expected pool warmed to 5 objects, got %+v", stats)
+	}
+	if pool.minCap != config.Min {
+		t.Fatalf("[ERR] expected Min kept at %d, got %d", config.Min, pool.minCap)
+	}
+
+	if _, err := NewGenericPoolFromState(nil, PoolState{Total: 5}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("[ERR] expected ErrInvalidConfig for a nil config, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats is a snapshot of the pool state.
+type PoolStats struct {
+	Idle        int // objects waiting in the pool
+	InUse       int // objects acquired and not yet released
+	Total       int // objects created and not yet closed
+	Max         int // max capacity of pool
+	WaiterCount int // acquires blocked waiting for an object
+
+	TotalCreateTime time.Duration // time spent in the factory so far
+	AvgCreateTime   time.Duration // average time of a factory call
+
+	AcquireFailureCount int // acquires failed for other reasons than a timeout or shutdown
+	DroppedEvents       int // events not delivered as the Events channel was full
+
+	TotalBytes int64 // transferred through objects, as recorded by RecordBytes
+}
+
+// current statistics of the pool
+func (p *GenericPool) Stats() PoolStats {
+	p.Lock()
+	defer p.Unlock()
+	idle := p.idleLen()
+	stats := PoolStats{
+		Idle:        idle,
+		InUse:       p.curNum - idle,
+		Total:       p.curNum,
+		Max:         p.maxCap,
+		WaiterCount: p.waiters,
+
+		TotalCreateTime: time.Duration(atomic.LoadInt64(&p.createNanos)),
+
+		AcquireFailureCount: int(atomic.LoadInt64(&p.acquireFailures)),
+		DroppedEvents:       int(atomic.LoadInt64(&p.droppedEvents)),
+
+		TotalBytes: p.totalBytes,
+	}
+	if n := atomic.LoadInt64(&p.createCount); n > 0 {
+		stats.AvgCreateTime = stats.TotalCreateTime / time.Duration(n)
+	}
+	return stats
+}
+
+// StatsStream sends a Stats snapshot every interval until ctx is cancelled,
+// then closes the channel. A snapshot is skipped while the previous one has
+// not been received.
+func (p *GenericPool) StatsStream(ctx context.Context, interval time.Duration) <-chan PoolStats {
+	if interval < minMonitorInterval {
+		interval = minMonitorInterval
+	}
+	ch := make(chan PoolStats, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				select {
+				case ch <- p.Stats():
+				default:
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// Utilization returns the share of Max currently in use, between 0 and 1. It
+// doesn't take the lock, so it is cheap enough for load shedding on every
+// request, at the cost of being a little behind concurrent changes.
+func (p *GenericPool) Utilization() float64 {
+	return float64(atomic.LoadInt64(&p.inUseCount)) / float64(atomic.LoadInt64(&p.maxCount))
+}
+
+// IdleRatio returns the share of Max sitting idle, between 0 and 1. Unlike
+// Utilization it takes the lock, as the idle objects aren't counted
+// atomically.
+func (p *GenericPool) IdleRatio() float64 {
+	p.Lock()
+	defer p.Unlock()
+	return float64(p.idleLen()) / float64(p.maxCap)
+}
+
+// FillRatio returns the share of Max created, idle or in use. It exceeds 1
+// while surplus objects are still in use after shrinking.
+func (p *GenericPool) FillRatio() float64 {
+	p.Lock()
+	defer p.Unlock()
+	return float64(p.curNum) / float64(p.maxCap)
+}
+
+// Verify checks the object accounting of the pool, and describes the first
+// inconsistency it finds in an ErrInconsistent error. The numbers are only
+// consistent while no acquire or release is in progress, so call it when the
+// pool is quiet, such as at the end of a test.
+func (p *GenericPool) Verify() error {
+	p.Lock()
+	defer p.Unlock()
+	idle := p.idleLen()
+	outstanding := p.curNum - idle - p.creating - p.resetting
+	switch {
+	case idle > p.curNum:
+		return fmt.Errorf("%w: %d idle objects, but only %d in total", ErrInconsistent, idle, p.curNum)
+	case p.curNum > p.maxCap && idle > 0:
+		// surplus after shrinking is fine, as long as it is in use
+		return fmt.Errorf("%w: %d objects exceed max %d, with %d idle", ErrInconsistent, p.curNum, p.maxCap, idle)
+	case outstanding != len(p.inUse):
+		return fmt.Errorf("%w: %d objects outstanding, but %d checked out", ErrInconsistent, outstanding, len(p.inUse))
+	case int64(len(p.inUse)) != atomic.LoadInt64(&p.inUseCount):
+		return fmt.Errorf("%w: %d objects checked out, but %d counted", ErrInconsistent, len(p.inUse), atomic.LoadInt64(&p.inUseCount))
+	}
+	return nil
+}
+
+// Repair recomputes the object accounting from the objects actually idle,
+// being created or reset, and checked out, and logs what it fixed. It is a
+// recovery tool for when Verify reports an inconsistency.
+func (p *GenericPool) Repair() error {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if n := int64(len(p.inUse)); n != atomic.LoadInt64(&p.inUseCount) {
+		p.logger.Printf("[POOL][WARN] repair: objects in use counted %d, fixed to %d.", atomic.LoadInt64(&p.inUseCount), n)
+		atomic.StoreInt64(&p.inUseCount, n)
+	}
+	actual := p.idleLen() + p.creating + p.resetting + len(p.inUse)
+	if actual != p.curNum {
+		p.logger.Printf("[POOL][WARN] repair: objects counted %d, fixed to %d.", p.curNum, actual)
+		p.curNum = actual
+		// waiters may create objects now, or must wait after all
+		p.broadcast()
+	}
+	// surplus idle objects after shrinking have no place to be
+	for p.idleLen() > 0 && p.curNum > p.maxCap {
+		poolObj, _ := p.takeIdle()
+		p.discard(poolObj)
+	}
+	return nil
+}
+
+// TagStat is what the acquires of one caller tag went through.
+type TagStat struct {
+	Acquires  int           // successful acquires
+	Failures  int           // failed acquires
+	TotalWait time.Duration // time spent in acquires, failed ones included
+	AvgWait   time.Duration // average time of an acquire
+}
+
+// AcquireTagged is Acquire, counted in the TaggedStats of tag, to tell callers
+// starved under contention from the others.
+func (p *GenericPool) AcquireTagged(tag string) (PoolObject, error) {
+	start := p.now()
+	poolObj, err := p.Acquire()
+	wait := p.since(start)
+	p.Lock()
+	defer p.Unlock()
+	stat := p.tagStats[tag]
+	if err != nil {
+		stat.Failures++
+	} else {
+		stat.Acquires++
+	}
+	stat.TotalWait += wait
+	stat.AvgWait = stat.TotalWait / time.Duration(stat.Acquires+stat.Failures)
+	p.tagStats[tag] = stat
+	return poolObj, err
+}
+
+// TaggedStats returns the stats of every tag AcquireTagged was called with.
+func (p *GenericPool) TaggedStats() map[string]TagStat {
+	p.Lock()
+	defer p.Unlock()
+	stats := make(map[string]TagStat, len(p.tagStats))
+	for tag, stat := range p.tagStats {
+		stats[tag] = stat
+	}
+	return stats
+}
+
+// RecordBytes counts n bytes transferred through an object in use, such as a
+// fasthttp client, towards Stats().TotalBytes and MaxBytesPerObject.
+func (p *GenericPool) RecordBytes(poolObj PoolObject, n int64) error {
+	if p.wrongPool(poolObj) {
+		return p.misuse(ErrWrongPool)
+	}
+	p.Lock()
+	defer p.Unlock()
+	c, ok := p.inUse[poolObj.id]
+	if !ok || poolObj.pool != p {
+		return p.misuse(ErrNotInUse)
+	}
+	c.bytes += n
+	p.totalBytes += n
+	return nil
+}
+
+// ObjectStat tells how much an object has been used, see ObjectStats.
+type ObjectStat struct {
+	ID        uint64
+	Uses      int           // times acquired, the current checkout included
+	InUseTime time.Duration // checked out in total, the current checkout included
+	Age       time.Duration
+	InUse     bool
+}
+
+// ObjectStats returns the use of every idle and checked out object, to spot
+// objects which are held for long, such as a slow connection.
+func (p *GenericPool) ObjectStats() []ObjectStat {
+	p.Lock()
+	defer p.Unlock()
+	now := p.now()
+	stat := func(poolObj PoolObject) ObjectStat {
+		return ObjectStat{
+			ID:        poolObj.id,
+			Uses:      poolObj.uses,
+			InUseTime: poolObj.held,
+			Age:       now.Sub(time.Unix(0, poolObj.CreateTime)),
+		}
+	}
+	var stats []ObjectStat
+	for _, poolObj := range p.idleObjects() {
+		stats = append(stats, stat(poolObj))
+	}
+	for _, c := range p.inUse {
+		s := stat(c.poolObj)
+		s.Uses++
+		s.InUseTime += now.Sub(c.since)
+		s.InUse = true
+		stats = append(stats, s)
+	}
+	return stats
+}
@@ -0,0 +1,375 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGenericPool_Utilization(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         4,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if u := pool.Utilization(); u != 0 {
+		t.Fatalf("[ERR] expected utilization 0, got %v", u)
+	}
+	var objs []PoolObject
+	for i := 1; i <= 4; i++ {
+		v, err := pool.Acquire()
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		objs = append(objs, v)
+		if u, want := pool.Utilization(), float64(i)/4; u != want {
+			t.Fatalf("[ERR] expected utilization %v, got %v", want, u)
+		}
+	}
+	pool.Release(objs[0])
+	pool.Close(objs[1])
+	if u := pool.Utilization(); u != 0.5 {
+		t.Fatalf("[ERR] expected utilization 0.5, got %v", u)
+	}
+	if err := pool.Resize(2); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if u := pool.Utilization(); u != 1 {
+		t.Fatalf("[ERR] expected utilization 1 after shrinking, got %v", u)
+	}
+}
+
+func TestGenericPool_Ratios(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         0,
+		Max:         4,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	expect := func(idle, fill float64) {
+		t.Helper()
+		if r := pool.IdleRatio(); r != idle {
+			t.Fatalf("[ERR] expected idle ratio %v, got %v", idle, r)
+		}
+		if r := pool.FillRatio(); r != fill {
+			t.Fatalf("[ERR] expected fill ratio %v, got %v", fill, r)
+		}
+	}
+	expect(0, 0)
+	var objs []PoolObject
+	for i := 0; i < 4; i++ {
+		v, _ := pool.Acquire()
+		objs = append(objs, v)
+	}
+	expect(0, 1)
+	pool.Release(objs[0])
+	pool.Close(objs[1])
+	expect(0.25, 0.75)
+	pool.Release(objs[2])
+	pool.Release(objs[3])
+	expect(0.75, 0.75)
+	objs = objs[:0]
+	for i := 0; i < 4; i++ {
+		v, _ := pool.Acquire()
+		objs = append(objs, v)
+	}
+	for _, v := range objs {
+		pool.Release(v)
+	}
+	expect(1, 1)
+}
+
+func BenchmarkGenericPool_Utilization(b *testing.B) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         4,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		b.Fatal("[ERR]", err)
+	}
+	// holding the lock proves Utilization doesn't need it
+	pool.Lock()
+	defer pool.Unlock()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Utilization()
+	}
+}
+
+func TestGenericPool_AcquireFailureCount(t *testing.T) {
+	var down int32
+	pool, err := NewGenericPool(&PoolConfig{
+		Min: 0,
+		Max: 2,
+		FactoryFunc: func() (interface{}, error) {
+			if atomic.LoadInt32(&down) == 1 {
+				return nil, errors.New("backend down")
+			}
+			return factory()
+		},
+		CloseFunc: closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	atomic.StoreInt32(&down, 1)
+	for i := 1; i <= 3; i++ {
+		if _, err := pool.Acquire(); err == nil {
+			t.Fatal("[ERR] expected acquire to fail")
+		}
+		if n := pool.Stats().AcquireFailureCount; n != i {
+			t.Fatalf("[ERR] expected %d failed acquires, got %d", i, n)
+		}
+	}
+	atomic.StoreInt32(&down, 0)
+	if _, err := pool.Acquire(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	// shutting down is no failure
+	pool.Shutdown()
+	pool.Acquire()
+	if n := pool.Stats().AcquireFailureCount; n != 3 {
+		t.Fatalf("[ERR] expected 3 failed acquires, got %d", n)
+	}
+}
+
+func TestGenericPool_Verify(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         4,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	v3, _ := pool.Acquire()
+	pool.Release(v1)
+	pool.Close(v2)
+	if err := pool.Resize(2); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.Verify(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool.Release(v3)
+	if err := pool.Verify(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+
+	// an object counted but nowhere to be found
+	pool.Lock()
+	pool.curNum++
+	pool.Unlock()
+	if err := pool.Verify(); !errors.Is(err, ErrInconsistent) {
+		t.Fatalf("[ERR] expected ErrInconsistent, got %v", err)
+	}
+	t.Log("[SUCC]", pool.Verify())
+}
+
+func TestGenericPool_TaggedStats(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+
+	// "fast" always finds the object idle, "slow" always waits for it
+	for i := 0; i < 3; i++ {
+		v, err := pool.AcquireTagged("fast")
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			pool.Release(v)
+		}()
+		w, err := pool.AcquireTagged("slow")
+		if err != nil {
+			t.Fatal("[ERR]", err)
+		}
+		pool.Release(w)
+	}
+	stats := pool.TaggedStats()
+	fast, slow := stats["fast"], stats["slow"]
+	if fast.Acquires != 3 || slow.Acquires != 3 || len(stats) != 2 {
+		t.Fatalf("[ERR] expected 3 acquires per tag, got %+v", stats)
+	}
+	if slow.AvgWait < 15*time.Millisecond || fast.AvgWait >= slow.AvgWait {
+		t.Fatalf("[ERR] expected slow tag to wait longer, got %+v", stats)
+	}
+	t.Log("[SUCC]", stats)
+}
+
+func TestGenericPool_StatsStream(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := pool.StatsStream(ctx, 5*time.Millisecond)
+	v, _ := pool.Acquire()
+	for i := 0; i < 3; i++ {
+		stats := <-stream
+		if stats.Total != 2 || stats.InUse > 1 {
+			t.Fatalf("[ERR] unexpected snapshot %+v", stats)
+		}
+	}
+	pool.Release(v)
+
+	cancel()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-stream:
+			if !ok {
+				t.Log("[SUCC] stream closed")
+				return
+			}
+		case <-deadline:
+			t.Fatal("[ERR] expected stream closed on cancel")
+		}
+	}
+}
+
+func TestGenericPool_RecordBytes(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:               1,
+		Max:               1,
+		FactoryFunc:       factory,
+		CloseFunc:         closer,
+		MaxBytesPerObject: 1000,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	v, _ := pool.Acquire()
+	first := v.ID()
+	pool.RecordBytes(v, 600)
+	pool.Release(v)
+
+	// the count carries over to the next checkout
+	v, _ = pool.Acquire()
+	if v.ID() != first {
+		t.Fatal("[ERR] expected object under the cap to be kept")
+	}
+	pool.RecordBytes(v, 400)
+	if n := pool.Stats().TotalBytes; n != 1000 {
+		t.Fatalf("[ERR] expected 1000 bytes recorded, got %d", n)
+	}
+	pool.Release(v)
+	if stats := pool.Stats(); stats.Total != 0 {
+		t.Fatalf("[ERR] expected object at the cap retired, got %+v", stats)
+	}
+	v, _ = pool.Acquire()
+	if v.ID() == first {
+		t.Fatal("[ERR] expected a new object")
+	}
+	if err := pool.RecordBytes(PoolObject{}, 1); err != ErrNotInUse {
+		t.Fatalf("[ERR] expected ErrNotInUse, got %v", err)
+	}
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_Repair(t *testing.T) {
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         3,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	defer pool.Shutdown()
+	v, _ := pool.Acquire()
+
+	// objects counted but nowhere to be found, and an in use count off
+	pool.Lock()
+	pool.curNum += 2
+	atomic.AddInt64(&pool.inUseCount, 1)
+	pool.Unlock()
+	if err := pool.Verify(); !errors.Is(err, ErrInconsistent) {
+		t.Fatalf("[ERR] expected ErrInconsistent, got %v", err)
+	}
+	if err := pool.Repair(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if err := pool.Verify(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if stats := pool.Stats(); stats.Total != 2 || stats.Idle != 1 {
+		t.Fatalf("[ERR] expected 2 objects, 1 idle, got %+v", stats)
+	}
+	// the slot lost to the drift is usable again
+	w, err := pool.Acquire()
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	if _, _, err := pool.AcquireOrCreate(); err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	pool.Release(v)
+	pool.Release(w)
+	t.Log("[SUCC]", pool.Stats())
+}
+
+func TestGenericPool_ObjectStats(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         2,
+		Max:         2,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		Clock:       clock,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v1, _ := pool.Acquire()
+	v2, _ := pool.Acquire()
+	clock.Advance(time.Second)
+	pool.Release(v1)
+	clock.Advance(2 * time.Second)
+	pool.Release(v2)
+
+	// the fast object is handed out again, and held briefly
+	v1, _, _ = pool.AcquireByID(v1.ID())
+	clock.Advance(time.Second)
+	stats := map[uint64]ObjectStat{}
+	for _, s := range pool.ObjectStats() {
+		stats[s.ID] = s
+	}
+	if s := stats[v1.ID()]; s.Uses != 2 || s.InUseTime != 2*time.Second || !s.InUse || s.Age != 4*time.Second {
+		t.Fatalf("[ERR] unexpected stats of object %d: %+v", v1.ID(), s)
+	}
+	if s := stats[v2.ID()]; s.Uses != 1 || s.InUseTime != 3*time.Second || s.InUse {
+		t.Fatalf("[ERR] unexpected stats of object %d: %+v", v2.ID(), s)
+	}
+	t.Log("[SUCC]", stats)
+}
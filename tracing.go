@@ -0,0 +1,36 @@
+package pool
+
+// Tracer starts spans, so the pool can be traced by OpenTelemetry or any other
+// tracing library without depending on it.
+type Tracer interface {
+	StartSpan(name string) Span
+}
+
+// Span is a traced operation of the pool.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) End()                             {}
+
+// startSpan starts a span by the tracer, if one is configured
+func (p *GenericPool) startSpan(name string) Span {
+	if p.tracer == nil {
+		return noopSpan{}
+	}
+	return p.tracer.StartSpan(name)
+}
+
+// endSpan records the object, or the error, of an operation and ends its span
+func endSpan(span Span, poolObj PoolObject, err error) {
+	if err != nil {
+		span.SetAttribute("error", err)
+	} else {
+		span.SetAttribute("pool.object_id", poolObj.id)
+	}
+	span.End()
+}
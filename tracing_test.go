@@ -0,0 +1,72 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSpan struct {
+	name  string
+	attrs map[string]interface{}
+}
+
+func (s *fakeSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *fakeSpan) End()                                       {}
+
+type fakeTracer struct {
+	sync.Mutex
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) StartSpan(name string) Span {
+	t.Lock()
+	defer t.Unlock()
+	span := &fakeSpan{name: name, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func TestGenericPool_Tracer(t *testing.T) {
+	tracer := &fakeTracer{}
+	pool, err := NewGenericPool(&PoolConfig{
+		Min:         1,
+		Max:         1,
+		FactoryFunc: factory,
+		CloseFunc:   closer,
+		Tracer:      tracer,
+	})
+	if err != nil {
+		t.Fatal("[ERR]", err)
+	}
+	v, _ := pool.Acquire()
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		pool.Release(v)
+	}()
+	// waits for the release
+	v, _ = pool.Acquire()
+	pool.Close(v)
+
+	tracer.Lock()
+	defer tracer.Unlock()
+	var names []string
+	for _, span := range tracer.spans {
+		names = append(names, span.name)
+	}
+	if len(names) != 4 || names[0] != "pool.Acquire" || names[3] != "pool.Close" {
+		t.Fatalf("[ERR] unexpected spans %v", names)
+	}
+	var waited *fakeSpan
+	for _, span := range tracer.spans {
+		if span.name == "pool.Acquire" && span.attrs["pool.wait_duration"].(time.Duration) >= 30*time.Millisecond {
+			waited = span
+		}
+	}
+	if waited == nil {
+		t.Fatal("[ERR] expected an acquire span recording the wait")
+	}
+	if waited.attrs["pool.created"] != false || waited.attrs["pool.object_id"] != v.ID() {
+		t.Fatalf("[ERR] unexpected attributes %v", waited.attrs)
+	}
+}
@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"fmt"
+	"time"
+)
+
+// ValidateConfig runs the checks NewGenericPool does on a config, without
+// creating a pool or calling the factory, for config validation tooling. The
+// error describes the first problem found, and matches ErrInvalidConfig in
+// errors.Is.
+func ValidateConfig(config *PoolConfig) error {
+	invalid := func(format string, v ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, fmt.Sprintf(format, v...))
+	}
+	switch {
+	case config == nil:
+		return invalid("config is nil")
+	case config.Max <= 0:
+		return invalid("Max %d is not positive", config.Max)
+	case config.Min < 0 || config.Min > config.Max:
+		return invalid("Min %d is not between 0 and Max %d", config.Min, config.Max)
+	case config.FactoryFunc == nil && config.TaggedFactoryFunc == nil &&
+		config.FactoryFuncStateful == nil && config.FactoryFuncCtx == nil:
+		return invalid("no factory")
+	case config.Ordering < OrderFIFO || config.Ordering > OrderLRU:
+		return invalid("unknown Ordering %d", config.Ordering)
+	case config.LessFunc != nil && config.Ordering != OrderFIFO:
+		return invalid("Ordering can't be combined with LessFunc")
+	case config.StrictFIFO && (config.idleLess() != nil || config.HedgedAcquire):
+		// both hand out objects out of order
+		return invalid("StrictFIFO can't be combined with LessFunc, OrderLRU or HedgedAcquire")
+	case config.ReplaceBefore > 0 && config.ReplaceBefore >= config.LiftTime:
+		return invalid("ReplaceBefore %v is not shorter than LiftTime %v", config.ReplaceBefore, config.LiftTime)
+	case config.InitFailurePolicy < InitContinue || config.InitFailurePolicy > InitRetrySlot:
+		return invalid("unknown InitFailurePolicy %d", config.InitFailurePolicy)
+	case config.SyncInit < 0 || config.SyncInit > config.Min:
+		return invalid("SyncInit %d is not between 0 and Min %d", config.SyncInit, config.Min)
+	case config.ErrorHistorySize < 0:
+		return invalid("negative ErrorHistorySize %d", config.ErrorHistorySize)
+	case config.ReleaseRetries < 0 || config.ReleaseRetries > maxReleaseRetries:
+		return invalid("ReleaseRetries %d is not between 0 and %d", config.ReleaseRetries, maxReleaseRetries)
+	case config.ParallelInit < 0 || config.OverflowMax < 0 || config.MaxBytesPerObject < 0 || config.CreateAheadFactor < 0:
+		return invalid("negative ParallelInit, OverflowMax, MaxBytesPerObject or CreateAheadFactor")
+	}
+	durations := map[string]time.Duration{
+		"LiftTime":             config.LiftTime,
+		"MaxCheckoutTime":      config.MaxCheckoutTime,
+		"MemoryCheckInterval":  config.MemoryCheckInterval,
+		"AutoShutdownAfter":    config.AutoShutdownAfter,
+		"SlowAcquireThreshold": config.SlowAcquireThreshold,
+		"ReplaceBefore":        config.ReplaceBefore,
+		"OverflowIdleTimeout":  config.OverflowIdleTimeout,
+		"MinIdleBeforeEvict":   config.MinIdleBeforeEvict,
+		"MinCreateInterval":    config.MinCreateInterval,
+	}
+	for name, d := range durations {
+		if d < 0 {
+			return invalid("negative %s %v", name, d)
+		}
+	}
+	for tag, d := range config.TagLifeTimes {
+		if d < 0 {
+			return invalid("negative LiftTime %v of tag %q", d, tag)
+		}
+	}
+	return nil
+}
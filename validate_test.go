@@ -0,0 +1,42 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateConfig(t *testing.T) {
+	less := func(a, b PoolObject) bool { return a.ID() < b.ID() }
+	cases := []struct {
+		name   string
+		config *PoolConfig
+		valid  bool
+	}{
+		{"valid", &PoolConfig{Min: 1, Max: 2, FactoryFunc: factory, LiftTime: time.Second, ReplaceBefore: time.Millisecond}, true},
+		{"nil", nil, false},
+		{"zero max", &PoolConfig{FactoryFunc: factory}, false},
+		{"min above max", &PoolConfig{Min: 3, Max: 2, FactoryFunc: factory}, false},
+		{"negative min", &PoolConfig{Min: -1, Max: 2, FactoryFunc: factory}, false},
+		{"no factory", &PoolConfig{Max: 2}, false},
+		{"fifo with less", &PoolConfig{Max: 2, FactoryFunc: factory, StrictFIFO: true, LessFunc: less}, false},
+		{"fifo with hedging", &PoolConfig{Max: 2, FactoryFunc: factory, StrictFIFO: true, HedgedAcquire: true}, false},
+		{"replace without lifetime", &PoolConfig{Max: 2, FactoryFunc: factory, ReplaceBefore: time.Second}, false},
+		{"replace beyond lifetime", &PoolConfig{Max: 2, FactoryFunc: factory, LiftTime: time.Second, ReplaceBefore: time.Second}, false},
+		{"init policy", &PoolConfig{Max: 2, FactoryFunc: factory, InitFailurePolicy: InitRetrySlot + 1}, false},
+		{"sync init above min", &PoolConfig{Min: 1, Max: 2, FactoryFunc: factory, SyncInit: 2}, false},
+		{"too many release retries", &PoolConfig{Max: 2, FactoryFunc: factory, ReleaseRetries: maxReleaseRetries + 1}, false},
+		{"negative overflow", &PoolConfig{Max: 2, FactoryFunc: factory, OverflowMax: -1}, false},
+		{"negative timeout", &PoolConfig{Max: 2, FactoryFunc: factory, MaxCheckoutTime: -time.Second}, false},
+	}
+	for _, c := range cases {
+		err := ValidateConfig(c.config)
+		if c.valid && err != nil {
+			t.Fatalf("[ERR] %s: %v", c.name, err)
+		}
+		if !c.valid && !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("[ERR] %s: expected ErrInvalidConfig, got %v", c.name, err)
+		}
+	}
+	t.Log("[SUCC]", len(cases))
+}